@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAttachmentBytes     = 10 << 20
+	maxAttachmentsPerOwner = 10
+	thumbnailSize          = 320
+)
+
+// allowedAttachmentTypes maps the sniffed content type of an upload to the
+// extension it is stored under. The client-supplied Content-Type is ignored.
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+type AttachmentsController struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	Signer  *storage.URLSigner
+}
+
+func NewAttachmentsController(db *gorm.DB, store storage.Storage, signer *storage.URLSigner) *AttachmentsController {
+	return &AttachmentsController{DB: db, Storage: store, Signer: signer}
+}
+
+func (c *AttachmentsController) RegisterRoutes(r chi.Router) {
+	r.Post("/posts/{postId}/attachments", c.UploadPostAttachment)
+	r.Post("/comments/{commentId}/attachments", c.UploadCommentAttachment)
+	r.Get("/attachments/{attachmentId}", c.ServeAttachment)
+	r.Get("/attachments/{attachmentId}/thumbnail", c.ServeThumbnail)
+	r.Delete("/attachments/{attachmentId}", c.DeleteAttachment)
+}
+
+func (c *AttachmentsController) UploadPostAttachment(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	var post models.Post
+	if err := c.DB.Select("id", "topic_id", "user_id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	c.upload(w, r, post.UserID, post.TopicID, &models.Attachment{PostID: &post.ID})
+}
+
+func (c *AttachmentsController) UploadCommentAttachment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := utils.ParseUintParam(r, "commentId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid commentId")
+		return
+	}
+
+	var comment models.Comment
+	if err := c.DB.
+		Select("id", "post_id", "user_id").
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "comment not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch comment")
+		return
+	}
+
+	c.upload(w, r, comment.UserID, comment.Post.TopicID, &models.Attachment{CommentID: &comment.ID})
+}
+
+// upload reads a multipart form with userId and file fields, checks that the
+// uploader owns the target, and stores the file (plus a thumbnail for
+// images) before recording att.
+func (c *AttachmentsController) upload(w http.ResponseWriter, r *http.Request, ownerID, topicID uint, att *models.Attachment) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentBytes+1<<20)
+	if err := r.ParseMultipartForm(maxAttachmentBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d MB limit", maxAttachmentBytes>>20))
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, "invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var userID uint
+	if _, err := fmt.Sscan(r.FormValue("userId"), &userID); err != nil || userID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if userID != ownerID {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	if !checkBan(w, c.DB, userID, &topicID) {
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentBytes+1))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "failed to read file")
+		return
+	}
+	if len(data) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "file is empty")
+		return
+	}
+	if len(data) > maxAttachmentBytes {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d MB limit", maxAttachmentBytes>>20))
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	ext, ok := allowedAttachmentTypes[contentType]
+	if !ok {
+		utils.WriteError(w, http.StatusUnsupportedMediaType, "file type not allowed")
+		return
+	}
+
+	existing := c.DB.Model(&models.Attachment{})
+	if att.PostID != nil {
+		existing = existing.Where("post_id = ?", *att.PostID)
+	} else {
+		existing = existing.Where("comment_id = ?", *att.CommentID)
+	}
+	var count int64
+	if err := existing.Count(&count).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error counting attachments")
+		return
+	}
+	if count >= maxAttachmentsPerOwner {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Sprintf("at most %d attachments allowed", maxAttachmentsPerOwner))
+		return
+	}
+
+	att.UserID = userID
+	att.FileName = cleanFileName(header.Filename, ext)
+	att.ContentType = contentType
+	att.Size = int64(len(data))
+	att.StorageKey = newStorageKey(ext)
+
+	var thumb []byte
+	if strings.HasPrefix(contentType, "image/") {
+		att.Width, att.Height, err = storage.ImageSize(data)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid image")
+			return
+		}
+		thumb, err = storage.Thumbnail(data, thumbnailSize)
+		if errors.Is(err, storage.ErrImageTooLarge) {
+			utils.WriteError(w, http.StatusBadRequest, "image dimensions too large")
+			return
+		}
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid image")
+			return
+		}
+		att.ThumbnailKey = strings.TrimSuffix(att.StorageKey, ext) + ".thumb.jpg"
+	}
+
+	ctx := r.Context()
+	if err := c.Storage.Put(ctx, att.StorageKey, bytes.NewReader(data)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to store file")
+		return
+	}
+	if thumb != nil {
+		if err := c.Storage.Put(ctx, att.ThumbnailKey, bytes.NewReader(thumb)); err != nil {
+			c.removeObjects(context.Background(), att.StorageKey, att.ThumbnailKey)
+			utils.WriteError(w, http.StatusInternalServerError, "failed to store thumbnail")
+			return
+		}
+	}
+
+	if err := c.DB.Create(att).Error; err != nil {
+		c.removeObjects(context.Background(), att.StorageKey, att.ThumbnailKey)
+		utils.WriteError(w, http.StatusInternalServerError, "failed to save attachment")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.ToAttachmentResponse(*att, c.Signer))
+}
+
+func (c *AttachmentsController) ServeAttachment(w http.ResponseWriter, r *http.Request) {
+	c.serve(w, r, false)
+}
+
+func (c *AttachmentsController) ServeThumbnail(w http.ResponseWriter, r *http.Request) {
+	c.serve(w, r, true)
+}
+
+// serve streams an attachment or its thumbnail. Access is granted by the
+// signed URL alone, so links embedded in responses work in <img> tags.
+func (c *AttachmentsController) serve(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	q := r.URL.Query()
+	if c.Signer == nil || !c.Signer.Verify(r.URL.Path, q.Get("expires"), q.Get("sig")) {
+		utils.WriteError(w, http.StatusForbidden, "invalid or expired link")
+		return
+	}
+
+	attachmentID, err := utils.ParseUintParam(r, "attachmentId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid attachmentId")
+		return
+	}
+
+	var att models.Attachment
+	if err := c.DB.First(&att, attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch attachment")
+		return
+	}
+
+	key, contentType, disposition := att.StorageKey, att.ContentType, "attachment"
+	if strings.HasPrefix(att.ContentType, "image/") {
+		disposition = "inline"
+	}
+	if thumbnail {
+		if att.ThumbnailKey == "" {
+			utils.WriteError(w, http.StatusNotFound, "attachment has no thumbnail")
+			return
+		}
+		key, contentType, disposition = att.ThumbnailKey, "image/jpeg", "inline"
+	}
+
+	obj, err := c.Storage.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to open attachment")
+		return
+	}
+	defer obj.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": att.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+
+	if rs, ok := obj.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", att.CreatedAt, rs)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, obj)
+}
+
+func (c *AttachmentsController) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	attachmentID, err := utils.ParseUintParam(r, "attachmentId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid attachmentId")
+		return
+	}
+
+	type deleteAttachmentRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req deleteAttachmentRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var att models.Attachment
+	if err := c.DB.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		Preload("Comment", func(db *gorm.DB) *gorm.DB { return db.Select("id", "post_id") }).
+		Preload("Comment.Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		First(&att, attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch attachment")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	owner := att.UserID == requester.ID
+	if !owner {
+		var topicID uint
+		if att.Post != nil {
+			topicID = att.Post.TopicID
+		} else if att.Comment != nil {
+			topicID = att.Comment.Post.TopicID
+		}
+		allowed, err := canModerateTopic(c.DB, requester, topicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Attachment{}, att.ID).Error; err != nil {
+			return err
+		}
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "attachment.delete", "attachment", att.ID, att, nil)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to delete attachment")
+		return
+	}
+
+	c.removeObjects(context.Background(), att.StorageKey, att.ThumbnailKey)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeObjects deletes stored attachment bytes. Failures only leave an
+// orphaned file behind, so they are logged rather than returned.
+func (c *AttachmentsController) removeObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := c.Storage.Delete(ctx, key); err != nil {
+			log.Printf("attachments: delete %s: %v", key, err)
+		}
+	}
+}
+
+const deleteAttachmentObjectsJob = "attachments.delete_objects"
+
+type attachmentObjectsPayload struct {
+	Keys []string `json:"keys"`
+}
+
+func (c *AttachmentsController) RegisterJobs(q *jobs.Queue) {
+	q.Register(deleteAttachmentObjectsJob, c.deleteObjects)
+}
+
+func (c *AttachmentsController) deleteObjects(ctx context.Context, _ *gorm.DB, raw json.RawMessage) error {
+	var p attachmentObjectsPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return err
+	}
+	c.removeObjects(ctx, p.Keys...)
+	return nil
+}
+
+// Deleting a post, comment or topic removes its attachment rows through
+// ON DELETE CASCADE. The queue* helpers below run in the delete's
+// transaction, before the delete, and enqueue removal of the stored files
+// those rows point to, so the files go only if the delete commits.
+
+func queuePostAttachmentCleanup(tx *gorm.DB, postID uint) error {
+	return queueAttachmentCleanup(tx,
+		"post_id = ? OR comment_id IN (SELECT id FROM comments WHERE post_id = ?)", postID, postID)
+}
+
+func queueCommentAttachmentCleanup(tx *gorm.DB, commentID uint) error {
+	return queueAttachmentCleanup(tx, "comment_id = ?", commentID)
+}
+
+func queueTopicAttachmentCleanup(tx *gorm.DB, topicID uint) error {
+	return queueAttachmentCleanup(tx,
+		"post_id IN (SELECT id FROM posts WHERE topic_id = ?) OR "+
+			"comment_id IN (SELECT comments.id FROM comments JOIN posts ON posts.id = comments.post_id WHERE posts.topic_id = ?)",
+		topicID, topicID)
+}
+
+func queueAttachmentCleanup(tx *gorm.DB, query string, args ...any) error {
+	var atts []models.Attachment
+	if err := tx.Select("storage_key", "thumbnail_key").Where(query, args...).Find(&atts).Error; err != nil {
+		return err
+	}
+	if len(atts) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, 2*len(atts))
+	for _, a := range atts {
+		keys = append(keys, a.StorageKey)
+		if a.ThumbnailKey != "" {
+			keys = append(keys, a.ThumbnailKey)
+		}
+	}
+	return jobs.Enqueue(tx, deleteAttachmentObjectsJob, attachmentObjectsPayload{Keys: keys})
+}
+
+func newStorageKey(ext string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s/%s%s", time.Now().UTC().Format("2006/01"), hex.EncodeToString(b), ext)
+}
+
+// cleanFileName keeps the base name of the uploaded file for display and
+// Content-Disposition, replacing control characters and falling back to a
+// generic name.
+func cleanFileName(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == "/" {
+		name = "file" + ext
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
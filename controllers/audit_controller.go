@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+type AuditController struct {
+	DB *gorm.DB
+}
+
+func NewAuditController(db *gorm.DB) *AuditController {
+	return &AuditController{DB: db}
+}
+
+func (c *AuditController) RegisterRoutes(r chi.Router) {
+	r.Get("/admin/audit-log", c.GetAuditLog)
+}
+
+func (c *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+	if requester.Role != "admin" {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	q := r.URL.Query()
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.AuditLog{})
+	if actorID, err := utils.ParseUintQuery(r, "actorId"); err == nil {
+		dbq = dbq.Where("actor_id = ?", actorID)
+	}
+	if v := q.Get("action"); v != "" {
+		dbq = dbq.Where("action = ?", v)
+	}
+	if v := q.Get("targetType"); v != "" {
+		dbq = dbq.Where("target_type = ?", v)
+	}
+	if targetID, err := utils.ParseUintQuery(r, "targetId"); err == nil {
+		dbq = dbq.Where("target_id = ?", targetID)
+	}
+	if v := q.Get("requestId"); v != "" {
+		dbq = dbq.Where("request_id = ?", v)
+	}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid since (RFC3339)")
+			return
+		}
+		dbq = dbq.Where("created_at >= ?", t)
+	}
+	if v := q.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid until (RFC3339)")
+			return
+		}
+		dbq = dbq.Where("created_at < ?", t)
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count audit log")
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := dbq.
+		Order("created_at DESC").
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&entries).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch audit log")
+		return
+	}
+
+	actorIDs := make([]uint, 0, len(entries))
+	for _, e := range entries {
+		actorIDs = append(actorIDs, e.ActorID)
+	}
+	actors := map[uint]models.User{}
+	if len(actorIDs) > 0 {
+		var users []models.User
+		if err := c.DB.Select("id", "username").Where("id IN ?", actorIDs).Find(&users).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch actors")
+			return
+		}
+		for _, u := range users {
+			actors[u.ID] = u
+		}
+	}
+
+	out := make([]types.AuditLogResponse, 0, len(entries))
+	for _, e := range entries {
+		actor, ok := actors[e.ActorID]
+		if !ok {
+			actor = models.User{ID: e.ActorID}
+		}
+		out = append(out, types.ToAuditLogResponse(e, actor))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.AuditLogResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// writeAudit appends an audit_log row using tx, so the entry commits or rolls
+// back together with the change it describes. before and after are stored as
+// JSON snapshots; either may be nil.
+func writeAudit(tx *gorm.DB, r *http.Request, actorID uint, action, targetType string, targetID uint, before, after any) error {
+	entry := models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  middleware.GetReqID(r.Context()),
+	}
+
+	if before != nil {
+		raw, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		entry.Before = raw
+	}
+	if after != nil {
+		raw, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		entry.After = raw
+	}
+
+	return tx.Create(&entry).Error
+}
@@ -114,6 +114,10 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkBan(w, c.DB, user.ID, nil) {
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"message": "login ok",
 		"user": map[string]any{
@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type BansController struct {
+	DB *gorm.DB
+}
+
+func NewBansController(db *gorm.DB) *BansController {
+	return &BansController{DB: db}
+}
+
+func (c *BansController) RegisterRoutes(r chi.Router) {
+	r.Get("/bans", c.GetBans)
+	r.Post("/bans", c.CreateBan)
+	r.Delete("/bans/{banId}", c.RevokeBan)
+}
+
+func (c *BansController) GetBans(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+	if !isPrivileged(requester) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Ban{})
+	if bannedID, err := utils.ParseUintQuery(r, "bannedUserId"); err == nil {
+		dbq = dbq.Where("user_id = ?", bannedID)
+	}
+	if r.URL.Query().Get("active") == "true" {
+		dbq = dbq.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count bans")
+		return
+	}
+
+	var bans []models.Ban
+	if err := dbq.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Preload("IssuedBy", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&bans).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch bans")
+		return
+	}
+
+	out := make([]types.BanResponse, 0, len(bans))
+	for _, b := range bans {
+		out = append(out, types.ToBanResponse(b))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.BanResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+func (c *BansController) CreateBan(w http.ResponseWriter, r *http.Request) {
+	type createBanRequest struct {
+		UserID       uint       `json:"userId"`
+		BannedUserID uint       `json:"bannedUserId"`
+		TopicID      *uint      `json:"topicId,omitempty"`
+		Reason       string     `json:"reason"`
+		ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	}
+
+	var req createBanRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.BannedUserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "bannedUserId is required")
+		return
+	}
+	if req.BannedUserID == req.UserID {
+		utils.WriteError(w, http.StatusBadRequest, "cannot ban yourself")
+		return
+	}
+	if req.Reason == "" {
+		utils.WriteError(w, http.StatusBadRequest, "reason cannot be empty")
+		return
+	}
+	if len(req.Reason) > 500 {
+		utils.WriteError(w, http.StatusBadRequest, "reason too long (max 500)")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, "expiresAt must be in the future")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "username", "role").First(&requester, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+	if !isPrivileged(requester) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var banned models.User
+	if err := c.DB.Select("id", "username", "role").First(&banned, req.BannedUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "banned user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	// Moderators can't ban each other or admins; only admins can.
+	if isPrivileged(banned) && requester.Role != "admin" {
+		utils.WriteError(w, http.StatusForbidden, "only admins can ban privileged users")
+		return
+	}
+
+	if req.TopicID != nil {
+		var topic models.Topic
+		if err := c.DB.Select("id").First(&topic, *req.TopicID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "topic not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking topic")
+			return
+		}
+	}
+
+	ban := models.Ban{
+		UserID:         banned.ID,
+		TopicID:        req.TopicID,
+		Reason:         req.Reason,
+		IssuedByUserID: requester.ID,
+		ExpiresAt:      req.ExpiresAt,
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+		if err := writeAudit(tx, r, requester.ID, "ban.create", "ban", ban.ID, nil, ban); err != nil {
+			return err
+		}
+		return tx.Create(&models.ModerationLog{
+			ModeratorID: requester.ID,
+			Action:      "ban",
+			TargetType:  "user",
+			TargetID:    banned.ID,
+			Note:        req.Reason,
+		}).Error
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to create ban")
+		return
+	}
+
+	ban.User = banned
+	ban.IssuedBy = requester
+	utils.WriteJSON(w, http.StatusCreated, types.ToBanResponse(ban))
+}
+
+func (c *BansController) RevokeBan(w http.ResponseWriter, r *http.Request) {
+	banID, err := utils.ParseUintParam(r, "banId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid banId")
+		return
+	}
+
+	type revokeBanRequest struct {
+		UserID uint `json:"userId"`
+	}
+
+	var req revokeBanRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var ban models.Ban
+	if err := c.DB.First(&ban, banID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "ban not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch ban")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+	if !isPrivileged(requester) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	if ban.RevokedAt != nil {
+		utils.WriteError(w, http.StatusConflict, "ban already revoked")
+		return
+	}
+
+	now := time.Now()
+	before := ban
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ban).Updates(map[string]any{
+			"revoked_at":         &now,
+			"revoked_by_user_id": requester.ID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := writeAudit(tx, r, requester.ID, "ban.revoke", "ban", ban.ID, before, ban); err != nil {
+			return err
+		}
+		return tx.Create(&models.ModerationLog{
+			ModeratorID: requester.ID,
+			Action:      "unban",
+			TargetType:  "user",
+			TargetID:    ban.UserID,
+		}).Error
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to revoke ban")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findActiveBan returns the ban that currently stops userID from acting in
+// topicID, or nil. With a nil topicID only site-wide bans are considered.
+// Permanent bans win over timed ones so the reported expiry is the latest.
+func findActiveBan(db *gorm.DB, userID uint, topicID *uint) (*models.Ban, error) {
+	dbq := db.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+
+	if topicID != nil {
+		dbq = dbq.Where("topic_id IS NULL OR topic_id = ?", *topicID)
+	} else {
+		dbq = dbq.Where("topic_id IS NULL")
+	}
+
+	var ban models.Ban
+	if err := dbq.Order("expires_at DESC NULLS FIRST").First(&ban).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// checkBan writes a 403 and returns false when userID is banned from acting
+// in topicID. The error names the expiry so clients can show it verbatim.
+func checkBan(w http.ResponseWriter, db *gorm.DB, userID uint, topicID *uint) bool {
+	ban, err := findActiveBan(db, userID, topicID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking bans")
+		return false
+	}
+	if ban == nil {
+		return true
+	}
+
+	utils.WriteJSON(w, http.StatusForbidden, map[string]any{
+		"error": banMessage(ban),
+		"ban": map[string]any{
+			"reason":    ban.Reason,
+			"topicId":   ban.TopicID,
+			"expiresAt": ban.ExpiresAt,
+		},
+	})
+	return false
+}
+
+func banMessage(ban *models.Ban) string {
+	scope := "from the forum"
+	if ban.TopicID != nil {
+		scope = "from this topic"
+	}
+	if ban.ExpiresAt != nil {
+		return "you are banned " + scope + " until " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return "you are banned " + scope + " permanently"
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hiddenAuthorsSQL selects the users whose content a viewer has chosen not
+// to see: everyone they muted or blocked. Both placeholders are the viewer.
+const hiddenAuthorsSQL = `SELECT muted_id FROM user_mutes WHERE muter_id = ?
+	UNION SELECT blocked_id FROM user_blocks WHERE blocker_id = ?`
+
+type BlocksController struct {
+	DB *gorm.DB
+}
+
+func NewBlocksController(db *gorm.DB) *BlocksController {
+	return &BlocksController{DB: db}
+}
+
+func (c *BlocksController) RegisterRoutes(r chi.Router) {
+	r.Get("/users/me/blocks", c.GetBlocks)
+	r.Post("/users/{profileId}/block", c.Block)
+	r.Delete("/users/{profileId}/block", c.Unblock)
+
+	r.Get("/users/me/mutes", c.GetMutes)
+	r.Post("/users/{profileId}/mute", c.Mute)
+	r.Delete("/users/{profileId}/mute", c.Unmute)
+}
+
+func (c *BlocksController) GetBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var blocks []models.UserBlock
+	if err := c.DB.
+		Where("blocker_id = ?", userID).
+		Preload("Blocked", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Find(&blocks).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch blocks")
+		return
+	}
+
+	out := make([]types.UserRelationResponse, 0, len(blocks))
+	for _, b := range blocks {
+		out = append(out, types.UserRelationResponse{User: types.ToUserPublic(b.Blocked), CreatedAt: b.CreatedAt})
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+func (c *BlocksController) GetMutes(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var mutes []models.UserMute
+	if err := c.DB.
+		Where("muter_id = ?", userID).
+		Preload("Muted", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Find(&mutes).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch mutes")
+		return
+	}
+
+	out := make([]types.UserRelationResponse, 0, len(mutes))
+	for _, m := range mutes {
+		out = append(out, types.UserRelationResponse{User: types.ToUserPublic(m.Muted), CreatedAt: m.CreatedAt})
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+func (c *BlocksController) Block(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := c.decodeRelation(w, r)
+	if !ok {
+		return
+	}
+	if err := c.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserBlock{BlockerID: actor, BlockedID: target}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to block user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *BlocksController) Unblock(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := c.decodeRelation(w, r)
+	if !ok {
+		return
+	}
+	if err := c.DB.
+		Where("blocker_id = ? AND blocked_id = ?", actor, target).
+		Delete(&models.UserBlock{}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to unblock user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *BlocksController) Mute(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := c.decodeRelation(w, r)
+	if !ok {
+		return
+	}
+	if err := c.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserMute{MuterID: actor, MutedID: target}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to mute user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *BlocksController) Unmute(w http.ResponseWriter, r *http.Request) {
+	actor, target, ok := c.decodeRelation(w, r)
+	if !ok {
+		return
+	}
+	if err := c.DB.
+		Where("muter_id = ? AND muted_id = ?", actor, target).
+		Delete(&models.UserMute{}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to unmute user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRelation reads the target user from the path and the acting user
+// from the {userId} body, checking both exist and differ.
+func (c *BlocksController) decodeRelation(w http.ResponseWriter, r *http.Request) (actorID, targetID uint, ok bool) {
+	targetID, err := utils.ParseUintParam(r, "profileId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid userId")
+		return 0, 0, false
+	}
+
+	type relationRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req relationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return 0, 0, false
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return 0, 0, false
+	}
+	if req.UserID == targetID {
+		utils.WriteError(w, http.StatusBadRequest, "cannot target yourself")
+		return 0, 0, false
+	}
+
+	var users []models.User
+	if err := c.DB.Select("id").Where("id IN ?", []uint{req.UserID, targetID}).Find(&users).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return 0, 0, false
+	}
+	if len(users) != 2 {
+		utils.WriteError(w, http.StatusNotFound, "user not found")
+		return 0, 0, false
+	}
+
+	return req.UserID, targetID, true
+}
+
+// blockersOf returns which of candidateIDs have blocked userID.
+func blockersOf(db *gorm.DB, userID uint, candidateIDs []uint) ([]uint, error) {
+	if len(candidateIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint
+	err := db.Model(&models.UserBlock{}).
+		Where("blocked_id = ? AND blocker_id IN ?", userID, candidateIDs).
+		Pluck("blocker_id", &ids).Error
+	return ids, err
+}
+
+// checkNotBlocked writes a 403 and returns false when any of recipientIDs
+// has blocked userID.
+func checkNotBlocked(w http.ResponseWriter, db *gorm.DB, userID uint, recipientIDs []uint, msg string) bool {
+	blockers, err := blockersOf(db, userID, recipientIDs)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking blocks")
+		return false
+	}
+	if len(blockers) > 0 {
+		utils.WriteError(w, http.StatusForbidden, msg)
+		return false
+	}
+	return true
+}
+
+// hideMutedAuthors is a scope that drops rows whose authorColumn is a user
+// the viewer muted or blocked. It is a no-op for anonymous viewers.
+func hideMutedAuthors(authorColumn string, viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewerID == 0 {
+			return db
+		}
+		return db.Where(authorColumn+" NOT IN ("+hiddenAuthorsSQL+")", viewerID, viewerID)
+	}
+}
+
+// hiddenAuthors returns the set of users viewerID muted or blocked.
+func hiddenAuthors(db *gorm.DB, viewerID uint) (map[uint]bool, error) {
+	out := map[uint]bool{}
+	if viewerID == 0 {
+		return out, nil
+	}
+	var ids []uint
+	if err := db.Raw(hiddenAuthorsSQL, viewerID, viewerID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out, nil
+}
+
+// collapseMuted reports whether the request asked for muted content to be
+// returned flagged (?muted=collapse) rather than left out.
+func collapseMuted(r *http.Request) bool {
+	return r.URL.Query().Get("muted") == "collapse"
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarksController struct {
+	DB     *gorm.DB
+	Signer *storage.URLSigner
+}
+
+func NewBookmarksController(db *gorm.DB, signer *storage.URLSigner) *BookmarksController {
+	return &BookmarksController{DB: db, Signer: signer}
+}
+
+func (c *BookmarksController) RegisterRoutes(r chi.Router) {
+	r.Get("/users/me/bookmarks", c.GetMyBookmarks)
+	r.Post("/posts/{postId}/bookmark", c.AddBookmark)
+	r.Delete("/posts/{postId}/bookmark", c.RemoveBookmark)
+}
+
+func (c *BookmarksController) GetMyBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Post{}).
+		Joins("JOIN bookmarks ON bookmarks.post_id = posts.id AND bookmarks.user_id = ?", userID)
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count bookmarks")
+		return
+	}
+
+	var posts []models.Post
+	if err := dbq.
+		Scopes(preloadPostDetails).
+		Order("bookmarks.created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&posts).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch bookmarks")
+		return
+	}
+
+	out := make([]types.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		out = append(out, types.ToPostResponse(p, c.Signer))
+	}
+	if err := applyViewerState(c.DB, userID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch bookmarks")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.PostResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// AddBookmark is idempotent: bookmarking an already saved post succeeds.
+func (c *BookmarksController) AddBookmark(w http.ResponseWriter, r *http.Request) {
+	bookmark, ok := c.decodeBookmark(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&bookmark).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to add bookmark")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *BookmarksController) RemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	bookmark, ok := c.decodeBookmark(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.
+		Where("user_id = ? AND post_id = ?", bookmark.UserID, bookmark.PostID).
+		Delete(&models.Bookmark{}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to remove bookmark")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeBookmark reads {postId} and the {userId} body shared by add and
+// remove, checking that both exist.
+func (c *BookmarksController) decodeBookmark(w http.ResponseWriter, r *http.Request) (models.Bookmark, bool) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return models.Bookmark{}, false
+	}
+
+	type bookmarkRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req bookmarkRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return models.Bookmark{}, false
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return models.Bookmark{}, false
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return models.Bookmark{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return models.Bookmark{}, false
+	}
+
+	var post models.Post
+	if err := c.DB.Select("id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return models.Bookmark{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return models.Bookmark{}, false
+	}
+
+	return models.Bookmark{UserID: user.ID, PostID: post.ID}, true
+}
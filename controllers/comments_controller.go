@@ -2,24 +2,31 @@ package controllers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"CVWO-Backend/markdown"
 	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/storage"
 	"CVWO-Backend/types"
 	"CVWO-Backend/utils"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CommentsController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Broker realtime.Broker
+	Signer *storage.URLSigner
 }
 
-func NewCommentsController(db *gorm.DB) *CommentsController {
-	return &CommentsController{DB: db}
+func NewCommentsController(db *gorm.DB, broker realtime.Broker, signer *storage.URLSigner) *CommentsController {
+	return &CommentsController{DB: db, Broker: broker, Signer: signer}
 }
 
 func (c *CommentsController) RegisterRoutes(r chi.Router) {
@@ -47,21 +54,48 @@ func (c *CommentsController) GetCommentsByPost(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	var comments []models.Comment
-	if err := c.DB.
+	viewer := viewerID(r)
+	dbq := c.DB.
 		Where("post_id = ?", postID).
-		Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "username")
-		}).
-		Order("created_at ASC").
-		Find(&comments).Error; err != nil {
+		Scopes(preloadCommentDetails)
+	// The accepted answer of a Q&A post is listed first.
+	if post.AcceptedCommentID != nil {
+		dbq = dbq.Order(clause.Expr{SQL: "CASE WHEN comments.id = ? THEN 0 ELSE 1 END", Vars: []any{*post.AcceptedCommentID}})
+	}
+	dbq = dbq.Order("created_at ASC")
+	if !collapseMuted(r) {
+		dbq = dbq.Scopes(hideMutedAuthors("comments.user_id", viewer))
+	}
+
+	var comments []models.Comment
+	if err := dbq.Find(&comments).Error; err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch comments")
 		return
 	}
 
 	out := make([]types.CommentResponse, 0, len(comments))
 	for _, cm := range comments {
-		out = append(out, types.ToCommentResponse(cm))
+		resp := types.ToCommentResponse(cm, c.Signer)
+		resp.Accepted = post.AcceptedCommentID != nil && *post.AcceptedCommentID == cm.ID
+		out = append(out, resp)
+	}
+	if err := applyCommentViewerState(c.DB, viewer, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch comments")
+		return
+	}
+
+	// Loading the thread marks it read. The accepted answer is listed first,
+	// so the marker is the highest ID rather than the last comment.
+	if viewer != 0 {
+		var upTo *uint
+		for i := range comments {
+			if upTo == nil || comments[i].ID > *upTo {
+				upTo = &comments[i].ID
+			}
+		}
+		if err := markPostRead(c.DB, viewer, post.ID, upTo); err != nil {
+			log.Printf("post reads: mark post %d for user %d: %v", post.ID, viewer, err)
+		}
 	}
 	utils.WriteJSON(w, http.StatusOK, out)
 }
@@ -115,19 +149,81 @@ func (c *CommentsController) CreateComment(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !checkBan(w, c.DB, user.ID, &post.TopicID) {
+		return
+	}
+
+	if post.UserID != user.ID && !checkNotBlocked(w, c.DB, user.ID, []uint{post.UserID}, "you cannot reply to this user") {
+		return
+	}
+
+	// Moderators can still reply to locked posts, e.g. to explain the lock.
+	if post.Locked {
+		allowed, err := canModerateTopic(c.DB, user, post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			msg := "post is locked"
+			if post.LockReason != "" {
+				msg += ": " + post.LockReason
+			}
+			utils.WriteError(w, http.StatusForbidden, msg)
+			return
+		}
+	}
+
 	comment := models.Comment{
-		PostID: postID,
-		UserID: req.UserID,
-		Body:   req.Body,
+		PostID:       postID,
+		UserID:       req.UserID,
+		Body:         req.Body,
+		RenderedBody: markdown.Render(req.Body),
 	}
 
-	if err := c.DB.Create(&comment).Error; err != nil {
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := countCommentCreated(tx, post.ID, comment.CreatedAt); err != nil {
+			return err
+		}
+
+		mentions, added, err := syncMentions(tx, user.ID, post.ID, &comment.ID, comment.Body)
+		if err != nil {
+			return err
+		}
+		comment.Mentions = mentions
+
+		if err := tx.Where("user_id = ? AND post_id = ?", user.ID, post.ID).Delete(&models.Draft{}).Error; err != nil {
+			return err
+		}
+
+		notifications := mentionNotifications(added, user.ID, post.ID, &comment.ID)
+		if post.UserID != user.ID {
+			notifications = append(notifications, models.Notification{
+				UserID:    post.UserID,
+				Type:      "reply",
+				ActorID:   user.ID,
+				PostID:    &post.ID,
+				CommentID: &comment.ID,
+			})
+		}
+		return createNotifications(tx, notifications)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to create comment")
 		return
 	}
 
 	comment.User = user
-	utils.WriteJSON(w, http.StatusCreated, types.ToCommentResponse(comment))
+	resp := types.ToCommentResponse(comment, c.Signer)
+
+	publishEvent(c.Broker, "comment.created", resp,
+		realtime.PostChannel(post.ID), realtime.TopicChannel(post.TopicID))
+	queueWebhooks(c.DB, "comment.created", resp)
+
+	utils.WriteJSON(w, http.StatusCreated, resp)
 }
 
 func (c *CommentsController) UpdateComment(w http.ResponseWriter, r *http.Request) {
@@ -158,7 +254,9 @@ func (c *CommentsController) UpdateComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	var comment models.Comment
-	if err := c.DB.First(&comment, commentID).Error; err != nil {
+	if err := c.DB.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		First(&comment, commentID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.WriteError(w, http.StatusNotFound, "comment not found")
 			return
@@ -178,9 +276,16 @@ func (c *CommentsController) UpdateComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	owner := comment.UserID == requester.ID
-	if !(isPrivileged(requester) || owner) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden")
-		return
+	if !owner {
+		allowed, err := canModerateTopic(c.DB, requester, comment.Post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
 	}
 
 	body := strings.TrimSpace(*req.Body)
@@ -190,23 +295,48 @@ func (c *CommentsController) UpdateComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	now := time.Now()
-	if err := c.DB.Model(&comment).Updates(map[string]any{
-		"body":      body,
-		"edited_at": &now,
-	}).Error; err != nil {
+	before := comment
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		// comment.Post is only partly loaded; don't let gorm save it back.
+		if err := tx.Model(&comment).Omit(clause.Associations).Updates(map[string]any{
+			"body":          body,
+			"rendered_body": markdown.Render(body),
+			"edited_at":     &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		_, added, err := syncMentions(tx, comment.UserID, comment.PostID, &comment.ID, body)
+		if err != nil {
+			return err
+		}
+		notifications := mentionNotifications(added, comment.UserID, comment.PostID, &comment.ID)
+		if err := createNotifications(tx, notifications); err != nil {
+			return err
+		}
+
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "comment.update", "comment", comment.ID, before, comment)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to update comment")
 		return
 	}
 
 	var updated models.Comment
 	if err := c.DB.
-		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Scopes(preloadCommentDetails).
 		First(&updated, commentID).Error; err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated comment")
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.ToCommentResponse(updated))
+	resp := types.ToCommentResponse(updated, c.Signer)
+	c.publishCommentEvent("comment.updated", resp, updated.PostID)
+
+	utils.WriteJSON(w, http.StatusOK, resp)
 }
 
 func (c *CommentsController) DeleteComment(w http.ResponseWriter, r *http.Request) {
@@ -232,7 +362,9 @@ func (c *CommentsController) DeleteComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	var comment models.Comment
-	if err := c.DB.First(&comment, commentID).Error; err != nil {
+	if err := c.DB.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		First(&comment, commentID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.WriteError(w, http.StatusNotFound, "comment not found")
 			return
@@ -252,15 +384,68 @@ func (c *CommentsController) DeleteComment(w http.ResponseWriter, r *http.Reques
 	}
 
 	owner := comment.UserID == requester.ID
-	if !(isPrivileged(requester) || owner) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden")
-		return
+	if !owner {
+		allowed, err := canModerateTopic(c.DB, requester, comment.Post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
 	}
 
-	if err := c.DB.Delete(&comment).Error; err != nil {
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := queueCommentAttachmentCleanup(tx, comment.ID); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Delete(&comment).Error; err != nil {
+			return err
+		}
+		if err := countCommentDeleted(tx, comment.PostID); err != nil {
+			return err
+		}
+		if err := clearAcceptedAnswer(tx, comment.ID); err != nil {
+			return err
+		}
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "comment.delete", "comment", comment.ID, comment, nil)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to delete comment")
 		return
 	}
 
+	c.publishCommentEvent("comment.deleted", map[string]any{
+		"id":     comment.ID,
+		"postId": comment.PostID,
+	}, comment.PostID)
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// publishCommentEvent sends a comment event to the post's stream, to the
+// stream of the topic the post belongs to and to webhooks.
+func (c *CommentsController) publishCommentEvent(eventType string, data any, postID uint) {
+	channels := []string{realtime.PostChannel(postID)}
+
+	var post models.Post
+	if err := c.DB.Select("id", "topic_id").First(&post, postID).Error; err == nil {
+		channels = append(channels, realtime.TopicChannel(post.TopicID))
+	}
+
+	publishEvent(c.Broker, eventType, data, channels...)
+	queueWebhooks(c.DB, eventType, data)
+}
+
+// preloadCommentDetails loads everything ToCommentResponse needs.
+func preloadCommentDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Preload("Mentions", func(db *gorm.DB) *gorm.DB { return db.Order("start ASC") }).
+		Preload("Mentions.MentionedUser", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") })
+}
@@ -0,0 +1,642 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxConversationParticipants = 10
+	maxMessageLength            = 5000
+)
+
+type ConversationsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+}
+
+func NewConversationsController(db *gorm.DB, broker realtime.Broker) *ConversationsController {
+	return &ConversationsController{DB: db, Broker: broker}
+}
+
+func (c *ConversationsController) RegisterRoutes(r chi.Router) {
+	r.Get("/conversations", c.GetConversations)
+	r.Post("/conversations", c.StartConversation)
+	r.Get("/conversations/{conversationId}/messages", c.GetMessages)
+	r.Post("/conversations/{conversationId}/messages", c.SendMessage)
+	r.Post("/conversations/{conversationId}/read", c.MarkRead)
+}
+
+func (c *ConversationsController) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Conversation{}).
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = conversations.id AND cp.user_id = ?", userID)
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count conversations")
+		return
+	}
+
+	var conversations []models.Conversation
+	if err := dbq.
+		Scopes(preloadParticipants).
+		Order("conversations.last_message_at DESC NULLS LAST").
+		Order("conversations.id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&conversations).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch conversations")
+		return
+	}
+
+	out, err := c.conversationResponses(userID, conversations)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch conversations")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.ConversationResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// StartConversation opens a conversation with the given users and posts the
+// first message. Starting a one-to-one conversation that already exists adds
+// the message to it instead.
+func (c *ConversationsController) StartConversation(w http.ResponseWriter, r *http.Request) {
+	type startConversationRequest struct {
+		UserID         uint   `json:"userId"`
+		ParticipantIDs []uint `json:"participantIds"`
+		Body           string `json:"body"`
+	}
+
+	var req startConversationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	others := make([]uint, 0, len(req.ParticipantIDs))
+	for _, id := range req.ParticipantIDs {
+		if id != 0 && id != req.UserID && !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "participantIds must include another user")
+		return
+	}
+	if len(others)+1 > maxConversationParticipants {
+		utils.WriteError(w, http.StatusBadRequest, "too many participants (max 10)")
+		return
+	}
+
+	body, ok := validateMessageBody(w, req.Body)
+	if !ok {
+		return
+	}
+
+	var sender models.User
+	if err := c.DB.Select("id", "username").First(&sender, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	if !checkBan(w, c.DB, sender.ID, nil) {
+		return
+	}
+
+	var found int64
+	if err := c.DB.Model(&models.User{}).Where("id IN ?", others).Count(&found).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking participants")
+		return
+	}
+	if int(found) != len(others) {
+		utils.WriteError(w, http.StatusBadRequest, "participant not found")
+		return
+	}
+
+	if !checkNotBlocked(w, c.DB, sender.ID, others, "you cannot message this user") {
+		return
+	}
+
+	var conversationID uint
+	status := http.StatusOK
+	var message models.Message
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if len(others) == 1 {
+			existing, err := findDirectConversation(tx, sender.ID, others[0])
+			if err != nil {
+				return err
+			}
+			conversationID = existing
+		}
+
+		if conversationID == 0 {
+			conversation := models.Conversation{CreatedByUserID: sender.ID}
+			if err := tx.Create(&conversation).Error; err != nil {
+				return err
+			}
+			conversationID = conversation.ID
+			status = http.StatusCreated
+
+			participants := make([]models.ConversationParticipant, 0, len(others)+1)
+			for _, id := range append([]uint{sender.ID}, others...) {
+				participants = append(participants, models.ConversationParticipant{ConversationID: conversation.ID, UserID: id})
+			}
+			if err := tx.Create(&participants).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		message, err = createMessage(tx, conversationID, sender.ID, body)
+		return err
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to start conversation")
+		return
+	}
+
+	var conversation models.Conversation
+	if err := c.DB.Scopes(preloadParticipants).First(&conversation, conversationID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch conversation")
+		return
+	}
+
+	message.Sender = sender
+	c.publishMessage(conversation, message)
+
+	out, err := c.conversationResponses(sender.ID, []models.Conversation{conversation})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch conversation")
+		return
+	}
+	utils.WriteJSON(w, status, out[0])
+}
+
+// GetMessages pages backwards through a conversation, newest first. Pass the
+// returned nextBefore as before to load older messages. Admins who are not
+// participants may read a conversation only once it has been reported, and
+// every such read is audited.
+func (c *ConversationsController) GetMessages(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := utils.ParseUintParam(r, "conversationId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid conversationId")
+		return
+	}
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var conversation models.Conversation
+	if !c.loadConversation(w, conversationID, &conversation) {
+		return
+	}
+
+	participant, err := isParticipant(c.DB, conversationID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking participants")
+		return
+	}
+	if !participant {
+		var requester models.User
+		if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusBadRequest, "user not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+			return
+		}
+		if requester.Role != "admin" {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+
+		var reports int64
+		if err := c.DB.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ?", "conversation", conversationID).
+			Count(&reports).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking reports")
+			return
+		}
+		if reports == 0 {
+			utils.WriteError(w, http.StatusForbidden, "conversation has not been reported")
+			return
+		}
+
+		if err := writeAudit(c.DB, r, requester.ID, "conversation.inspect", "conversation", conversationID, nil, nil); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to record audit entry")
+			return
+		}
+	}
+
+	limit := 50
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+
+	dbq := c.DB.
+		Where("conversation_id = ?", conversationID).
+		Preload("Sender", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("id DESC").
+		Limit(limit + 1)
+	if before, err := utils.ParseUintQuery(r, "before"); err == nil {
+		dbq = dbq.Where("id < ?", before)
+	}
+
+	var messages []models.Message
+	if err := dbq.Find(&messages).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch messages")
+		return
+	}
+
+	var resp types.MessagePageResponse
+	if len(messages) > limit {
+		messages = messages[:limit]
+		resp.NextBefore = messages[limit-1].ID
+	}
+	resp.Items = make([]types.MessageResponse, 0, len(messages))
+	for _, m := range messages {
+		resp.Items = append(resp.Items, types.ToMessageResponse(m))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (c *ConversationsController) SendMessage(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := utils.ParseUintParam(r, "conversationId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid conversationId")
+		return
+	}
+
+	type sendMessageRequest struct {
+		UserID uint   `json:"userId"`
+		Body   string `json:"body"`
+	}
+
+	var req sendMessageRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	body, ok := validateMessageBody(w, req.Body)
+	if !ok {
+		return
+	}
+
+	var conversation models.Conversation
+	if !c.loadConversation(w, conversationID, &conversation) {
+		return
+	}
+
+	sender, ok := c.loadParticipant(w, conversationID, req.UserID)
+	if !ok {
+		return
+	}
+
+	if !checkBan(w, c.DB, sender.ID, nil) {
+		return
+	}
+
+	var recipients []uint
+	if err := c.DB.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id <> ?", conversationID, sender.ID).
+		Pluck("user_id", &recipients).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking participants")
+		return
+	}
+	if !checkNotBlocked(w, c.DB, sender.ID, recipients, "you cannot message this user") {
+		return
+	}
+
+	var message models.Message
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		message, err = createMessage(tx, conversationID, sender.ID, body)
+		return err
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to send message")
+		return
+	}
+
+	message.Sender = sender
+	if err := c.DB.Scopes(preloadParticipants).First(&conversation, conversationID).Error; err == nil {
+		c.publishMessage(conversation, message)
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.ToMessageResponse(message))
+}
+
+// MarkRead moves the caller's read receipt forward to messageId, or to the
+// latest message when it is omitted. Receipts never move backwards.
+func (c *ConversationsController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := utils.ParseUintParam(r, "conversationId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid conversationId")
+		return
+	}
+
+	type markReadRequest struct {
+		UserID    uint `json:"userId"`
+		MessageID uint `json:"messageId"`
+	}
+
+	var req markReadRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var conversation models.Conversation
+	if !c.loadConversation(w, conversationID, &conversation) {
+		return
+	}
+
+	reader, ok := c.loadParticipant(w, conversationID, req.UserID)
+	if !ok {
+		return
+	}
+
+	var message models.Message
+	dbq := c.DB.Select("id").Where("conversation_id = ?", conversationID)
+	if req.MessageID != 0 {
+		dbq = dbq.Where("id = ?", req.MessageID)
+	}
+	if err := dbq.Order("id DESC").First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "message not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch message")
+		return
+	}
+
+	now := time.Now()
+	res := c.DB.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, reader.ID).
+		Where("last_read_message_id IS NULL OR last_read_message_id < ?", message.ID).
+		Updates(map[string]any{"last_read_message_id": message.ID, "last_read_at": now})
+	if res.Error != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update read receipt")
+		return
+	}
+
+	var participant models.ConversationParticipant
+	if err := c.DB.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Where("conversation_id = ? AND user_id = ?", conversationID, reader.ID).
+		First(&participant).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch read receipt")
+		return
+	}
+
+	if res.RowsAffected > 0 {
+		var userIDs []uint
+		if err := c.DB.Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id <> ?", conversationID, reader.ID).
+			Pluck("user_id", &userIDs).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch participants")
+			return
+		}
+
+		channels := make([]string, 0, len(userIDs))
+		for _, id := range userIDs {
+			channels = append(channels, realtime.UserChannel(id))
+		}
+		publishEvent(c.Broker, "conversation.read", map[string]any{
+			"conversationId":    conversationID,
+			"userId":            reader.ID,
+			"lastReadMessageId": participant.LastReadMessageID,
+		}, channels...)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToParticipantResponse(participant))
+}
+
+func (c *ConversationsController) loadConversation(w http.ResponseWriter, conversationID uint, dst *models.Conversation) bool {
+	if err := c.DB.First(dst, conversationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "conversation not found")
+			return false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch conversation")
+		return false
+	}
+	return true
+}
+
+// loadParticipant loads userID and checks that they belong to the
+// conversation. Non-participants get a 404 so conversation IDs can't be
+// probed.
+func (c *ConversationsController) loadParticipant(w http.ResponseWriter, conversationID, userID uint) (models.User, bool) {
+	ok, err := isParticipant(c.DB, conversationID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking participants")
+		return models.User{}, false
+	}
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, "conversation not found")
+		return models.User{}, false
+	}
+
+	var user models.User
+	if err := c.DB.Select("id", "username").First(&user, userID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// conversationResponses maps conversations for viewerID, adding each one's
+// latest message and the viewer's unread count.
+func (c *ConversationsController) conversationResponses(viewerID uint, conversations []models.Conversation) ([]types.ConversationResponse, error) {
+	out := make([]types.ConversationResponse, 0, len(conversations))
+	if len(conversations) == 0 {
+		return out, nil
+	}
+
+	ids := make([]uint, 0, len(conversations))
+	for _, cv := range conversations {
+		ids = append(ids, cv.ID)
+	}
+
+	var lastMessages []models.Message
+	if err := c.DB.
+		Raw(`SELECT DISTINCT ON (conversation_id) * FROM messages
+			WHERE conversation_id IN ? ORDER BY conversation_id, id DESC`, ids).
+		Scan(&lastMessages).Error; err != nil {
+		return nil, err
+	}
+	senderIDs := make([]uint, 0, len(lastMessages))
+	for _, m := range lastMessages {
+		senderIDs = append(senderIDs, m.SenderID)
+	}
+	var senders []models.User
+	if len(senderIDs) > 0 {
+		if err := c.DB.Select("id", "username").Where("id IN ?", senderIDs).Find(&senders).Error; err != nil {
+			return nil, err
+		}
+	}
+	sendersByID := make(map[uint]models.User, len(senders))
+	for _, u := range senders {
+		sendersByID[u.ID] = u
+	}
+	lastByConversation := make(map[uint]types.MessageResponse, len(lastMessages))
+	for _, m := range lastMessages {
+		m.Sender = sendersByID[m.SenderID]
+		lastByConversation[m.ConversationID] = types.ToMessageResponse(m)
+	}
+
+	type unreadRow struct {
+		ConversationID uint
+		Count          int64
+	}
+	var unread []unreadRow
+	if err := c.DB.
+		Raw(`SELECT m.conversation_id, COUNT(*) AS count FROM messages m
+			JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id = ?
+			WHERE m.conversation_id IN ? AND m.sender_id <> ? AND m.id > COALESCE(cp.last_read_message_id, 0)
+			GROUP BY m.conversation_id`, viewerID, ids, viewerID).
+		Scan(&unread).Error; err != nil {
+		return nil, err
+	}
+	unreadByConversation := make(map[uint]int64, len(unread))
+	for _, u := range unread {
+		unreadByConversation[u.ConversationID] = u.Count
+	}
+
+	for _, cv := range conversations {
+		resp := types.ToConversationResponse(cv)
+		if m, ok := lastByConversation[cv.ID]; ok {
+			resp.LastMessage = &m
+		}
+		resp.UnreadCount = unreadByConversation[cv.ID]
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// publishMessage pushes a new message to every participant's user stream.
+func (c *ConversationsController) publishMessage(conversation models.Conversation, message models.Message) {
+	channels := make([]string, 0, len(conversation.Participants))
+	for _, p := range conversation.Participants {
+		channels = append(channels, realtime.UserChannel(p.UserID))
+	}
+	publishEvent(c.Broker, "message.created", types.ToMessageResponse(message), channels...)
+}
+
+// createMessage stores a message, bumps the conversation's last activity and
+// marks it read for its sender.
+func createMessage(tx *gorm.DB, conversationID, senderID uint, body string) (models.Message, error) {
+	message := models.Message{ConversationID: conversationID, SenderID: senderID, Body: body}
+	if err := tx.Omit(clause.Associations).Create(&message).Error; err != nil {
+		return message, err
+	}
+	if err := tx.Model(&models.Conversation{}).
+		Where("id = ?", conversationID).
+		Update("last_message_at", message.CreatedAt).Error; err != nil {
+		return message, err
+	}
+	err := tx.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, senderID).
+		Updates(map[string]any{"last_read_message_id": message.ID, "last_read_at": message.CreatedAt}).Error
+	return message, err
+}
+
+func isParticipant(db *gorm.DB, conversationID, userID uint) (bool, error) {
+	var count int64
+	if err := db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// findDirectConversation returns the conversation that has exactly a and b
+// as participants, or 0 if there is none. It first takes a transaction-level
+// advisory lock on the pair, so two first messages between the same users
+// can't both miss and create a conversation each; tx must be a transaction.
+func findDirectConversation(tx *gorm.DB, a, b uint) (uint, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?::int, ?::int)", min(a, b), max(a, b)).Error; err != nil {
+		return 0, err
+	}
+
+	var ids []uint
+	err := tx.Raw(`SELECT cp.conversation_id FROM conversation_participants cp
+		WHERE cp.conversation_id IN (SELECT conversation_id FROM conversation_participants WHERE user_id = ?)
+		GROUP BY cp.conversation_id
+		HAVING COUNT(*) = 2 AND bool_or(cp.user_id = ?)
+		ORDER BY cp.conversation_id
+		LIMIT 1`, a, b).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+func validateMessageBody(w http.ResponseWriter, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		utils.WriteError(w, http.StatusBadRequest, "body cannot be empty")
+		return "", false
+	}
+	if len([]rune(body)) > maxMessageLength {
+		utils.WriteError(w, http.StatusBadRequest, "body too long (max 5000)")
+		return "", false
+	}
+	return body, true
+}
+
+func preloadParticipants(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Participants", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, user_id ASC") }).
+		Preload("Participants.User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") })
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// The counters below are denormalized onto topics and posts so lists don't
+// need a COUNT per row. They are adjusted in the same transaction as the
+// insert or delete rather than from a job, so a count never disagrees with
+// the rows it counts; db.ReconcileCounters repairs any drift.
+
+func countPostCreated(tx *gorm.DB, topicID uint, at time.Time) error {
+	return tx.Model(&models.Topic{}).Where("id = ?", topicID).UpdateColumns(map[string]any{
+		"post_count":   gorm.Expr("post_count + 1"),
+		"last_post_at": gorm.Expr("GREATEST(last_post_at, ?)", at),
+	}).Error
+}
+
+// countPostDeleted runs after the post row is gone, so last_post_at falls
+// back to the newest remaining post.
+func countPostDeleted(tx *gorm.DB, topicID uint) error {
+	return tx.Model(&models.Topic{}).Where("id = ?", topicID).UpdateColumns(map[string]any{
+		"post_count":   gorm.Expr("GREATEST(post_count - 1, 0)"),
+		"last_post_at": gorm.Expr("(SELECT MAX(created_at) FROM posts WHERE topic_id = ?)", topicID),
+	}).Error
+}
+
+func countCommentCreated(tx *gorm.DB, postID uint, at time.Time) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]any{
+		"comment_count":   gorm.Expr("comment_count + 1"),
+		"last_comment_at": gorm.Expr("GREATEST(last_comment_at, ?)", at),
+	}).Error
+}
+
+func countCommentDeleted(tx *gorm.DB, postID uint) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]any{
+		"comment_count":   gorm.Expr("GREATEST(comment_count - 1, 0)"),
+		"last_comment_at": gorm.Expr("(SELECT MAX(created_at) FROM comments WHERE post_id = ?)", postID),
+	}).Error
+}
@@ -0,0 +1,484 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/storage"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DraftsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+	Signer *storage.URLSigner
+}
+
+func NewDraftsController(db *gorm.DB, broker realtime.Broker, signer *storage.URLSigner) *DraftsController {
+	return &DraftsController{DB: db, Broker: broker, Signer: signer}
+}
+
+func (c *DraftsController) RegisterRoutes(r chi.Router) {
+	r.Get("/users/me/drafts", c.GetMyDrafts)
+	r.Post("/drafts", c.CreateDraft)
+	r.Get("/drafts/{draftId}", c.GetDraft)
+	r.Put("/drafts/{draftId}", c.SaveDraft)
+	r.Delete("/drafts/{draftId}", c.DeleteDraft)
+	r.Post("/drafts/{draftId}/publish", c.PublishDraft)
+}
+
+func (c *DraftsController) RegisterJobs(q *jobs.Queue) {
+	q.Register(publishDraftJob, c.publishScheduledDraft)
+}
+
+type draftRequest struct {
+	UserID    uint       `json:"userId"`
+	TopicID   *uint      `json:"topicId,omitempty"`
+	PostID    *uint      `json:"postId,omitempty"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Tags      []string   `json:"tags"`
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+// applyDraftRequest copies the editable fields onto d. Drafts may be
+// incomplete, so only limits are checked, unless the draft is being
+// scheduled, in which case it must be publishable as is.
+func applyDraftRequest(d *models.Draft, req draftRequest) error {
+	if d.PostID != nil {
+		if req.PublishAt != nil {
+			return errors.New("comment drafts cannot be scheduled")
+		}
+		d.Body = req.Body
+		return nil
+	}
+
+	if len(strings.TrimSpace(req.Title)) > 120 {
+		return errors.New("title too long (max 120)")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	if req.PublishAt != nil {
+		in := newPost{TopicID: *d.TopicID, Title: req.Title, Body: req.Body, Tags: tags}
+		if err := in.validate(); err != nil {
+			return err
+		}
+	}
+
+	d.Title = req.Title
+	d.Body = req.Body
+	d.Tags = tags
+	d.PublishAt = nil
+	if req.PublishAt != nil {
+		// Postgres keeps microseconds; match it so the publish job can
+		// recognise its schedule.
+		t := req.PublishAt.Truncate(time.Microsecond)
+		d.PublishAt = &t
+	}
+	d.PublishError = ""
+	return nil
+}
+
+func (c *DraftsController) GetMyDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Draft{}).Where("user_id = ?", userID)
+	switch r.URL.Query().Get("kind") {
+	case "post":
+		dbq = dbq.Where("topic_id IS NOT NULL")
+	case "comment":
+		dbq = dbq.Where("post_id IS NOT NULL")
+	case "":
+	default:
+		utils.WriteError(w, http.StatusBadRequest, "kind must be post or comment")
+		return
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count drafts")
+		return
+	}
+
+	var drafts []models.Draft
+	if err := dbq.
+		Order("updated_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&drafts).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch drafts")
+		return
+	}
+
+	out := make([]types.DraftResponse, 0, len(drafts))
+	for _, d := range drafts {
+		out = append(out, types.ToDraftResponse(d))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.DraftResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// CreateDraft starts a post draft (topicId) or saves the user's comment
+// draft on a post (postId). Comment drafts are upserted, so clients can
+// autosave without tracking the draft id.
+func (c *DraftsController) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	var req draftRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if (req.TopicID == nil) == (req.PostID == nil) {
+		utils.WriteError(w, http.StatusBadRequest, "exactly one of topicId or postId is required")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	draft := models.Draft{UserID: user.ID, TopicID: req.TopicID, PostID: req.PostID}
+	if req.TopicID != nil {
+		if err := c.DB.Select("id").First(&models.Topic{}, *req.TopicID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "topic not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking topic")
+			return
+		}
+	} else {
+		if err := c.DB.Select("id").First(&models.Post{}, *req.PostID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "post not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking post")
+			return
+		}
+	}
+
+	if err := applyDraftRequest(&draft, req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := http.StatusCreated
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		dbq := tx.Omit(clause.Associations)
+		if draft.PostID != nil {
+			status = http.StatusOK
+			dbq = dbq.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"body", "updated_at"}),
+			})
+		}
+		if err := dbq.Create(&draft).Error; err != nil {
+			return err
+		}
+		return scheduleDraft(tx, draft, nil)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to save draft")
+		return
+	}
+
+	utils.WriteJSON(w, status, types.ToDraftResponse(draft))
+}
+
+func (c *DraftsController) GetDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	draft, ok := c.findOwnDraft(w, r, userID)
+	if !ok {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToDraftResponse(draft))
+}
+
+// SaveDraft replaces the draft's content with the request, which is what an
+// autosave sends. Omitting publishAt unschedules a post draft.
+func (c *DraftsController) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	var req draftRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	draft, ok := c.findOwnDraft(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	previous := draft.PublishAt
+	if err := applyDraftRequest(&draft, req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&draft).Error; err != nil {
+			return err
+		}
+		return scheduleDraft(tx, draft, previous)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to save draft")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToDraftResponse(draft))
+}
+
+func (c *DraftsController) DeleteDraft(w http.ResponseWriter, r *http.Request) {
+	type deleteDraftRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req deleteDraftRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	draft, ok := c.findOwnDraft(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.Delete(&draft).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to delete draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishDraft publishes a post draft now instead of waiting for its
+// schedule.
+func (c *DraftsController) PublishDraft(w http.ResponseWriter, r *http.Request) {
+	type publishDraftRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req publishDraftRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	draft, ok := c.findOwnDraft(w, r, req.UserID)
+	if !ok {
+		return
+	}
+	if draft.TopicID == nil {
+		utils.WriteError(w, http.StatusConflict, "comment drafts are published by commenting on the post")
+		return
+	}
+	if !checkBan(w, c.DB, draft.UserID, draft.TopicID) {
+		return
+	}
+
+	var post models.Post
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var locked models.Draft
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, draft.ID).Error; err != nil {
+			return err
+		}
+		var err error
+		post, err = publishDraft(tx, locked)
+		return err
+	})
+	if err != nil {
+		var problem draftProblem
+		switch {
+		case errors.As(err, &problem):
+			utils.WriteError(w, http.StatusBadRequest, problem.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.WriteError(w, http.StatusNotFound, "draft not found")
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, "failed to publish draft")
+		}
+		return
+	}
+
+	resp := publishPostCreated(c.DB, c.Broker, c.Signer, post)
+
+	out := []types.PostResponse{resp}
+	if err := applyViewerState(c.DB, draft.UserID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, out[0])
+}
+
+const publishDraftJob = "drafts.publish"
+
+type publishDraftPayload struct {
+	DraftID   uint      `json:"draftId"`
+	PublishAt time.Time `json:"publishAt"`
+}
+
+// scheduleDraft enqueues the job that publishes d at its PublishAt unless
+// it was already scheduled for that time, so autosaves don't pile up jobs.
+// Jobs left over from an earlier schedule notice the change and do nothing.
+func scheduleDraft(tx *gorm.DB, d models.Draft, previous *time.Time) error {
+	if d.PublishAt == nil || (previous != nil && previous.Equal(*d.PublishAt)) {
+		return nil
+	}
+	payload := publishDraftPayload{DraftID: d.ID, PublishAt: *d.PublishAt}
+	return jobs.Enqueue(tx, publishDraftJob, payload, jobs.RunAt(*d.PublishAt))
+}
+
+// publishScheduledDraft is the publishDraftJob handler. A draft that can no
+// longer be published is unscheduled and keeps the reason in PublishError.
+func (c *DraftsController) publishScheduledDraft(_ context.Context, db *gorm.DB, payload json.RawMessage) error {
+	var p publishDraftPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	var post *models.Post
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var draft models.Draft
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("topic_id IS NOT NULL").
+			First(&draft, p.DraftID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if draft.PublishAt == nil || !draft.PublishAt.Equal(p.PublishAt) {
+			return nil
+		}
+
+		published, err := publishDraft(tx, draft)
+		var problem draftProblem
+		if errors.As(err, &problem) {
+			return tx.Model(&draft).Omit(clause.Associations).Updates(map[string]any{
+				"publish_at":    nil,
+				"publish_error": problem.Error(),
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+		post = &published
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if post != nil {
+		publishPostCreated(db, c.Broker, c.Signer, *post)
+	}
+	return nil
+}
+
+// draftProblem is a reason a draft can't be published that the author has
+// to fix, as opposed to a database error.
+type draftProblem string
+
+func (p draftProblem) Error() string { return string(p) }
+
+// publishDraft creates the post for a post draft in tx, the same way
+// CreatePost does, and deletes the draft. Publish the result with
+// publishPostCreated once tx commits.
+func publishDraft(tx *gorm.DB, d models.Draft) (models.Post, error) {
+	in := newPost{TopicID: *d.TopicID, Title: d.Title, Body: d.Body, Tags: d.Tags}
+	if err := in.validate(); err != nil {
+		return models.Post{}, draftProblem(err.Error())
+	}
+
+	var user models.User
+	if err := tx.Select("id", "username", "role").First(&user, d.UserID).Error; err != nil {
+		return models.Post{}, err
+	}
+	ban, err := findActiveBan(tx, user.ID, d.TopicID)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if ban != nil {
+		return models.Post{}, draftProblem(banMessage(ban))
+	}
+
+	post, err := insertPost(tx, user, in)
+	if err != nil {
+		return post, err
+	}
+	return post, tx.Delete(&d).Error
+}
+
+// findOwnDraft loads the {draftId} draft, answering 404 for drafts that
+// belong to someone else.
+func (c *DraftsController) findOwnDraft(w http.ResponseWriter, r *http.Request, userID uint) (models.Draft, bool) {
+	var draft models.Draft
+	draftID, err := utils.ParseUintParam(r, "draftId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid draftId")
+		return draft, false
+	}
+
+	if err := c.DB.Where("user_id = ?", userID).First(&draft, draftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "draft not found")
+			return draft, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch draft")
+		return draft, false
+	}
+	return draft, true
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type EventsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+}
+
+func NewEventsController(db *gorm.DB, broker realtime.Broker) *EventsController {
+	return &EventsController{DB: db, Broker: broker}
+}
+
+func (c *EventsController) RegisterRoutes(r chi.Router) {
+	r.Get("/posts/{postId}/events", c.StreamPostEvents)
+	r.Get("/topics/{topicId}/events", c.StreamTopicEvents)
+	r.Get("/users/{userId}/events", c.StreamUserEvents)
+}
+
+func (c *EventsController) StreamPostEvents(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	var post models.Post
+	if err := c.DB.Select("id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	c.stream(w, r, realtime.PostChannel(postID))
+}
+
+func (c *EventsController) StreamTopicEvents(w http.ResponseWriter, r *http.Request) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return
+	}
+
+	var topic models.Topic
+	if err := c.DB.Select("id").First(&topic, topicID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "topic not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topic")
+		return
+	}
+
+	c.stream(w, r, realtime.TopicChannel(topicID))
+}
+
+// StreamUserEvents carries one user's notifications and direct message
+// events. Like the rest of the API it takes the caller's word for who they
+// are: without sessions, anyone who knows a user ID can subscribe.
+func (c *EventsController) StreamUserEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintParam(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid userId")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch user")
+		return
+	}
+
+	c.stream(w, r, realtime.UserChannel(userID))
+}
+
+// stream writes events for channel as text/event-stream until the client
+// disconnects. A comment line is sent periodically so idle proxies keep the
+// connection open.
+func (c *EventsController) stream(w http.ResponseWriter, r *http.Request, channel string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	events, unsubscribe := c.Broker.Subscribe(channel)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, ev.Data)
+			flusher.Flush()
+		}
+	}
+}
+
+// publishEvent sends the same event to every channel given. Failures are
+// logged rather than returned since the write that triggered the event has
+// already been committed.
+func publishEvent(b realtime.Broker, eventType string, data any, channels ...string) {
+	if b == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("realtime: encode %s: %v", eventType, err)
+		return
+	}
+	for _, ch := range channels {
+		ev := realtime.Event{Channel: ch, Type: eventType, Data: raw}
+		if err := b.Publish(context.Background(), ev); err != nil {
+			log.Printf("realtime: publish %s to %s: %v", eventType, ch, err)
+		}
+	}
+}
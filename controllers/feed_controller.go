@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"CVWO-Backend/db"
+	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hotWindow bounds the posts a hot feed ranks. The score below counts each
+// candidate's comments, so without a bound every page would cost a count
+// per post in the followed topics' whole history. Week-old posts score far
+// below fresh ones anyway.
+const hotWindow = 7 * 24 * time.Hour
+
+// hotScoreSQL ranks posts by comments per age, decaying with the age in
+// hours so fresh discussions outrank old busy ones. Both placeholders are
+// the reference time: only comments written by then count, so a post's
+// score for a given time doesn't move as new comments arrive. That costs a
+// count per post instead of reading posts.comment_count, but lets hot feeds
+// page by score.
+const hotScoreSQL = `(1 + (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.created_at <= ?)) / ` +
+	`POWER(EXTRACT(EPOCH FROM (?::timestamptz - posts.created_at)) / 3600.0 + 2, 1.5)`
+
+// feedCursor is the opaque position handed back as nextCursor. "new" feeds
+// page by (created_at, id). "hot" feeds freeze the scoring time in Now and
+// page by (score, id) as of that time; posts and comments created later are
+// left out until the client starts over. Activity feeds also record the
+// Kind of the last item, as posts and comments are interleaved.
+type feedCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id,omitempty"`
+	Kind      string    `json:"k,omitempty"`
+	Now       time.Time `json:"n"`
+	Score     float64   `json:"s,omitempty"`
+}
+
+func (fc feedCursor) encode() string {
+	b, _ := json.Marshal(fc)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeFeedCursor(raw string) (feedCursor, error) {
+	var fc feedCursor
+	if raw == "" {
+		return fc, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return fc, err
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		return fc, err
+	}
+	return fc, nil
+}
+
+type FeedController struct {
+	DB     *gorm.DB
+	Signer *storage.URLSigner
+}
+
+func NewFeedController(db *gorm.DB, signer *storage.URLSigner) *FeedController {
+	return &FeedController{DB: db, Signer: signer}
+}
+
+func (c *FeedController) RegisterRoutes(r chi.Router) {
+	r.Get("/feed", c.GetFeed)
+	r.Get("/feed/following", c.GetFollowingFeed)
+}
+
+// GetFeed merges posts from the topics the user follows. Users who follow
+// nothing (or anonymous requests) get the default seeded topics instead.
+func (c *FeedController) GetFeed(w http.ResponseWriter, r *http.Request) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "new"
+	}
+	if sort != "new" && sort != "hot" {
+		utils.WriteError(w, http.StatusBadRequest, "sort must be new or hot")
+		return
+	}
+
+	limit, cursor, ok := parseFeedPage(w, r)
+	if !ok {
+		return
+	}
+
+	userID := viewerID(r)
+
+	var topicIDs []uint
+	if userID != 0 {
+		if err := c.DB.Model(&models.TopicFollow{}).
+			Where("user_id = ?", userID).
+			Pluck("topic_id", &topicIDs).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch followed topics")
+			return
+		}
+	}
+
+	fallback := len(topicIDs) == 0
+	if fallback {
+		if err := c.DB.Model(&models.Topic{}).
+			Where("title IN ?", db.DefaultTopicTitles()).
+			Pluck("id", &topicIDs).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch default topics")
+			return
+		}
+	}
+
+	dbq := c.DB.Model(&models.Post{}).Where("posts.topic_id IN ?", topicIDs)
+	if !collapseMuted(r) {
+		dbq = dbq.Scopes(hideMutedAuthors("posts.user_id", userID))
+	}
+
+	posts, next, err := pageFeedPosts(dbq, sort, cursor, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+		return
+	}
+
+	out := make([]types.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		out = append(out, types.ToPostResponse(p, c.Signer))
+	}
+	if err := applyViewerState(c.DB, userID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.FeedResponse{
+		Items:      out,
+		NextCursor: next,
+		Fallback:   fallback,
+	})
+}
+
+// parseFeedPage reads limit (default 20, max 100) and cursor, writing a 400
+// and returning ok=false when the cursor is invalid.
+func parseFeedPage(w http.ResponseWriter, r *http.Request) (limit int, cursor feedCursor, ok bool) {
+	limit = 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+
+	cursor, err := decodeFeedCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid cursor")
+		return 0, feedCursor{}, false
+	}
+	return limit, cursor, true
+}
+
+// pageFeedPosts fetches one page of posts from dbq in the given sort order,
+// returning the cursor for the next page ("" on the last one).
+func pageFeedPosts(dbq *gorm.DB, sort string, cursor feedCursor, limit int) ([]models.Post, string, error) {
+	dbq = dbq.Scopes(preloadPostDetails).Limit(limit + 1)
+
+	now := cursor.Now
+	switch sort {
+	case "hot":
+		if now.IsZero() {
+			now = time.Now()
+		}
+		dbq = dbq.Where("posts.created_at > ? AND posts.created_at <= ?", now.Add(-hotWindow), now)
+		if cursor.ID != 0 {
+			dbq = dbq.Where(clause.Expr{
+				SQL:  "(" + hotScoreSQL + ", posts.id) < (?, ?)",
+				Vars: []any{now, now, cursor.Score, cursor.ID},
+			})
+		}
+		dbq = dbq.Order(clause.OrderBy{Expression: clause.Expr{SQL: hotScoreSQL + " DESC, posts.id DESC", Vars: []any{now, now}}})
+	default:
+		if cursor.ID != 0 {
+			dbq = dbq.Where("(posts.created_at, posts.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		dbq = dbq.Order("posts.created_at DESC").Order("posts.id DESC")
+	}
+
+	var posts []models.Post
+	if err := dbq.Find(&posts).Error; err != nil {
+		return nil, "", err
+	}
+	if len(posts) <= limit {
+		return posts, "", nil
+	}
+
+	posts = posts[:limit]
+	last := posts[len(posts)-1]
+	if sort != "hot" {
+		return posts, feedCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode(), nil
+	}
+
+	// Read the last score back from Postgres rather than recomputing it in
+	// Go, so the next page's comparison uses exactly the same value.
+	var score float64
+	if err := dbq.Session(&gorm.Session{NewDB: true}).Model(&models.Post{}).
+		Select(hotScoreSQL, now, now).
+		Where("posts.id = ?", last.ID).
+		Scan(&score).Error; err != nil {
+		return nil, "", err
+	}
+	return posts, feedCursor{Now: now, Score: score, ID: last.ID}.encode(), nil
+}
+
+// followedActivitySQL pages through rows of one table (posts or comments)
+// written by the users someone follows. The LATERAL subquery walks each
+// followee's (user_id, created_at) index for at most limit rows, so the cost
+// grows with the number of followees rather than their total history.
+const followedActivitySQL = `SELECT x.id FROM user_follows f
+CROSS JOIN LATERAL (
+	SELECT t.id, t.created_at FROM %s t
+	WHERE t.user_id = f.followee_id AND %s
+	ORDER BY t.created_at DESC, t.id DESC
+	LIMIT ?
+) x
+WHERE f.follower_id = ?
+ORDER BY x.created_at DESC, x.id DESC
+LIMIT ?`
+
+// GetFollowingFeed interleaves recent posts and comments by the users the
+// requester follows, newest first. At equal timestamps posts sort before
+// comments, which the cursor conditions below rely on.
+func (c *FeedController) GetFollowingFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	limit, cursor, ok := parseFeedPage(w, r)
+	if !ok {
+		return
+	}
+
+	postCond, commentCond := "TRUE", "TRUE"
+	var postArgs, commentArgs []any
+	switch cursor.Kind {
+	case "post":
+		postCond, postArgs = "(t.created_at, t.id) < (?, ?)", []any{cursor.CreatedAt, cursor.ID}
+		commentCond, commentArgs = "t.created_at <= ?", []any{cursor.CreatedAt}
+	case "comment":
+		postCond, postArgs = "t.created_at < ?", []any{cursor.CreatedAt}
+		commentCond, commentArgs = "(t.created_at, t.id) < (?, ?)", []any{cursor.CreatedAt, cursor.ID}
+	}
+
+	var postIDs, commentIDs []uint
+	if err := c.DB.Raw(fmt.Sprintf(followedActivitySQL, "posts", postCond),
+		append(postArgs, limit+1, userID, limit+1)...).Scan(&postIDs).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+		return
+	}
+	if err := c.DB.Raw(fmt.Sprintf(followedActivitySQL, "comments", commentCond),
+		append(commentArgs, limit+1, userID, limit+1)...).Scan(&commentIDs).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+		return
+	}
+
+	var posts []models.Post
+	if len(postIDs) > 0 {
+		if err := c.DB.Scopes(preloadPostDetails).
+			Where("id IN ?", postIDs).
+			Order("created_at DESC").Order("id DESC").
+			Find(&posts).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+			return
+		}
+	}
+
+	var comments []models.Comment
+	if len(commentIDs) > 0 {
+		if err := c.DB.Scopes(preloadCommentDetails).
+			Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "title") }).
+			Where("id IN ?", commentIDs).
+			Order("created_at DESC").Order("id DESC").
+			Find(&comments).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+			return
+		}
+	}
+
+	postOut := make([]types.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		postOut = append(postOut, types.ToPostResponse(p, c.Signer))
+	}
+	if err := applyViewerState(c.DB, userID, postOut); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch feed")
+		return
+	}
+
+	items := make([]types.ActivityItem, 0, limit)
+	var last feedCursor
+	pi, ci := 0, 0
+	for len(items) < limit && (pi < len(posts) || ci < len(comments)) {
+		takePost := ci >= len(comments) ||
+			(pi < len(posts) && !posts[pi].CreatedAt.Before(comments[ci].CreatedAt))
+		if takePost {
+			items = append(items, types.ActivityItem{Type: "post", CreatedAt: posts[pi].CreatedAt, Post: &postOut[pi]})
+			last = feedCursor{CreatedAt: posts[pi].CreatedAt, ID: posts[pi].ID, Kind: "post"}
+			pi++
+			continue
+		}
+		cr := types.ToCommentResponse(comments[ci], c.Signer)
+		items = append(items, types.ActivityItem{
+			Type:      "comment",
+			CreatedAt: comments[ci].CreatedAt,
+			Comment:   &cr,
+			PostTitle: comments[ci].Post.Title,
+		})
+		last = feedCursor{CreatedAt: comments[ci].CreatedAt, ID: comments[ci].ID, Kind: "comment"}
+		ci++
+	}
+
+	out := types.ActivityFeedResponse{Items: items}
+	if pi < len(posts) || ci < len(comments) {
+		out.NextCursor = last.encode()
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
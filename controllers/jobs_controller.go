@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// JobsController lets admins inspect the background job queue and retry
+// dead jobs.
+type JobsController struct {
+	DB *gorm.DB
+}
+
+func NewJobsController(db *gorm.DB) *JobsController {
+	return &JobsController{DB: db}
+}
+
+func (c *JobsController) RegisterRoutes(r chi.Router) {
+	r.Get("/admin/jobs", c.GetJobs)
+	r.Post("/admin/jobs/{jobId}/retry", c.RetryJob)
+}
+
+func (c *JobsController) GetJobs(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if _, ok := requireAdmin(w, c.DB, userID); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Job{})
+	if v := q.Get("state"); v != "" {
+		dbq = dbq.Where("state = ?", v)
+	}
+	if v := q.Get("kind"); v != "" {
+		dbq = dbq.Where("kind = ?", v)
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count jobs")
+		return
+	}
+
+	var rows []models.Job
+	if err := dbq.
+		Order("updated_at DESC, id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&rows).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch jobs")
+		return
+	}
+
+	out := make([]types.JobResponse, 0, len(rows))
+	for _, j := range rows {
+		out = append(out, types.ToJobResponse(j))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.JobResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// RetryJob puts a dead job back on the queue with a fresh set of attempts.
+func (c *JobsController) RetryJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := utils.ParseUintParam(r, "jobId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid jobId")
+		return
+	}
+
+	type retryJobRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req retryJobRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	requester, ok := requireAdmin(w, c.DB, req.UserID)
+	if !ok {
+		return
+	}
+
+	var job models.Job
+	if err := c.DB.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch job")
+		return
+	}
+	if job.State != jobs.StateDead {
+		utils.WriteError(w, http.StatusConflict, "only dead jobs can be retried")
+		return
+	}
+
+	before := job
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&job).Updates(map[string]any{
+			"state":    jobs.StateQueued,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "job.retry", "job", job.ID, before, job)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to retry job")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToJobResponse(job))
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	liveWriteWait  = 10 * time.Second
+	livePingPeriod = 20 * time.Second
+	liveReadLimit  = 1024
+)
+
+type LiveController struct {
+	DB       *gorm.DB
+	Hub      *realtime.PresenceHub
+	upgrader websocket.Upgrader
+}
+
+func NewLiveController(db *gorm.DB, hub *realtime.PresenceHub, allowedOrigins []string) *LiveController {
+	return &LiveController{
+		DB:  db,
+		Hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || slices.Contains(allowedOrigins, origin)
+			},
+		},
+	}
+}
+
+func (c *LiveController) RegisterRoutes(r chi.Router) {
+	r.Get("/posts/{postId}/live", c.Live)
+}
+
+// Live upgrades to a WebSocket that reports who is viewing and typing on a
+// post. Browsers cannot send a body with the handshake, so the user is
+// identified by the userId query parameter.
+func (c *LiveController) Live(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var post models.Post
+	if err := c.DB.Select("id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id", "username").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusUnauthorized, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		return
+	}
+
+	client := &liveClient{conn: conn, send: make(chan []byte, 16), done: make(chan struct{})}
+	go client.writePump()
+
+	c.Hub.Join(postID, client, realtime.PresenceUser{ID: user.ID, Username: user.Username})
+	defer c.Hub.Leave(postID, client)
+	defer client.Close()
+
+	conn.SetReadLimit(liveReadLimit)
+	conn.SetPongHandler(func(string) error {
+		c.Hub.Heartbeat(postID, client)
+		return nil
+	})
+
+	for {
+		var msg struct {
+			Type   string `json:"type"`
+			Typing bool   `json:"typing"`
+		}
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "heartbeat":
+			c.Hub.Heartbeat(postID, client)
+		case "typing":
+			c.Hub.SetTyping(postID, client, msg.Typing)
+		}
+	}
+}
+
+// liveClient adapts a WebSocket connection to realtime.PresenceClient. All
+// writes happen on writePump so the connection has a single writer.
+type liveClient struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *liveClient) Send(msg []byte) bool {
+	select {
+	case <-l.done:
+		return false
+	case l.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *liveClient) Close() {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		_ = l.conn.Close()
+	})
+}
+
+func (l *liveClient) writePump() {
+	ticker := time.NewTicker(livePingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case msg := <-l.send:
+			_ = l.conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := l.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				l.Close()
+				return
+			}
+		case <-ticker.C:
+			_ = l.conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := l.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				l.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"regexp"
+	"slices"
+	"unicode/utf16"
+
+	"CVWO-Backend/markdown"
+	"CVWO-Backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mentionPattern matches @name where name may contain dots and dashes but
+// not end with one, so "@alice." mentions alice. The leading group stops
+// e-mail addresses like bob@example.com from counting.
+var mentionPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_@.])@([A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_])?)`)
+
+type mentionMatch struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// parseMentions finds @username spans in body, skipping code spans and code
+// blocks. Offsets are UTF-16 code units and include the @.
+func parseMentions(body string) []mentionMatch {
+	code := markdown.CodeRanges(body)
+	var out []mentionMatch
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		at := m[4] - 1
+		if slices.ContainsFunc(code, func(r [2]int) bool { return at >= r[0] && at < r[1] }) {
+			continue
+		}
+		start := utf16Len(body[:at])
+		out = append(out, mentionMatch{
+			Username: body[m[4]:m[5]],
+			Start:    start,
+			End:      start + utf16Len(body[at:m[5]]),
+		})
+	}
+	return out
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// syncMentions replaces the stored mentions for a post body (commentID nil)
+// or a comment body and returns them along with the users who were not
+// mentioned there before. Unknown usernames and users who blocked the author
+// are ignored, and authors never count as newly mentioned by themselves.
+func syncMentions(tx *gorm.DB, authorID, postID uint, commentID *uint, body string) ([]models.Mention, []uint, error) {
+	matches := parseMentions(body)
+
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, m.Username)
+	}
+
+	users := map[string]models.User{}
+	if len(names) > 0 {
+		var found []models.User
+		if err := tx.Select("id", "username").Where("username IN ?", names).Find(&found).Error; err != nil {
+			return nil, nil, err
+		}
+		ids := make([]uint, 0, len(found))
+		for _, u := range found {
+			ids = append(ids, u.ID)
+		}
+		blockers, err := blockersOf(tx, authorID, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, u := range found {
+			if !slices.Contains(blockers, u.ID) {
+				users[u.Username] = u
+			}
+		}
+	}
+
+	scope := func() *gorm.DB {
+		if commentID == nil {
+			return tx.Where("post_id = ? AND comment_id IS NULL", postID)
+		}
+		return tx.Where("comment_id = ?", *commentID)
+	}
+
+	var previous []uint
+	if err := scope().Model(&models.Mention{}).Distinct().Pluck("mentioned_user_id", &previous).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := scope().Delete(&models.Mention{}).Error; err != nil {
+		return nil, nil, err
+	}
+
+	mentions := make([]models.Mention, 0, len(matches))
+	for _, m := range matches {
+		u, ok := users[m.Username]
+		if !ok {
+			continue
+		}
+		mention := models.Mention{
+			MentionedUserID: u.ID,
+			Start:           m.Start,
+			End:             m.End,
+			MentionedUser:   u,
+		}
+		if commentID == nil {
+			id := postID
+			mention.PostID = &id
+		} else {
+			mention.CommentID = commentID
+		}
+		mentions = append(mentions, mention)
+	}
+
+	if len(mentions) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&mentions).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	before := map[uint]bool{authorID: true}
+	for _, id := range previous {
+		before[id] = true
+	}
+	var added []uint
+	for _, m := range mentions {
+		if !before[m.MentionedUserID] {
+			before[m.MentionedUserID] = true
+			added = append(added, m.MentionedUserID)
+		}
+	}
+
+	return mentions, added, nil
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []mentionMatch
+	}{
+		{"plain", "hi @alice", []mentionMatch{{"alice", 3, 9}}},
+		{"trailing dot", "thanks @alice.", []mentionMatch{{"alice", 7, 13}}},
+		{"dotted name", "@a.b-c ok", []mentionMatch{{"a.b-c", 0, 6}}},
+		{"email", "mail bob@example.com", nil},
+		{"double at", "@@alice", nil},
+		{"utf-16 offsets", "😀 @bob", []mentionMatch{{"bob", 3, 7}}},
+		{"emphasis", "*@alice*", []mentionMatch{{"alice", 1, 7}}},
+		{"code span", "run `@alice` now", nil},
+		{"double backtick span", "``a @alice ` b``", nil},
+		{"after code span", "`x` @bob", []mentionMatch{{"bob", 4, 8}}},
+		{"fenced block", "```\n@alice\n```\n@bob", []mentionMatch{{"bob", 15, 19}}},
+		{"tilde fence", "~~~go\nx := \"@alice\"\n~~~", nil},
+		{"indented block", "text\n\n    @alice\n\n@bob", []mentionMatch{{"bob", 18, 22}}},
+		{"fence in list", "- item\n\n  ```\n  @alice\n  ```", nil},
+		{"unclosed backtick", "`@alice", []mentionMatch{{"alice", 1, 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
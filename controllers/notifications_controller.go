@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+}
+
+func NewNotificationsController(db *gorm.DB, broker realtime.Broker) *NotificationsController {
+	return &NotificationsController{DB: db, Broker: broker}
+}
+
+func (c *NotificationsController) RegisterRoutes(r chi.Router) {
+	r.Get("/notifications", c.GetNotifications)
+	r.Post("/notifications/read", c.MarkRead)
+}
+
+func (c *NotificationsController) RegisterJobs(q *jobs.Queue) {
+	q.Register(deliverNotificationsJob, c.deliverNotifications)
+}
+
+func (c *NotificationsController) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if r.URL.Query().Get("unread") == "true" {
+		dbq = dbq.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+
+	var notifications []models.Notification
+	if err := dbq.
+		Preload("Actor", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&notifications).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch notifications")
+		return
+	}
+
+	out := make([]types.NotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		out = append(out, types.ToNotificationResponse(n))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.NotificationResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// MarkRead marks the given notifications as read, or all of them when ids is
+// omitted.
+func (c *NotificationsController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	type markReadRequest struct {
+		UserID uint   `json:"userId"`
+		IDs    []uint `json:"ids,omitempty"`
+	}
+
+	var req markReadRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	dbq := c.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", req.UserID)
+	if len(req.IDs) > 0 {
+		dbq = dbq.Where("id IN ?", req.IDs)
+	}
+
+	res := dbq.Update("read_at", time.Now())
+	if res.Error != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update notifications")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{"updated": res.RowsAffected})
+}
+
+const deliverNotificationsJob = "notifications.deliver"
+
+type deliverNotificationsPayload struct {
+	IDs []uint `json:"ids"`
+}
+
+// createNotifications stores ns in tx along with a job that pushes them to
+// connected clients once the transaction commits.
+func createNotifications(tx *gorm.DB, ns []models.Notification) error {
+	if len(ns) == 0 {
+		return nil
+	}
+	if err := tx.Omit(clause.Associations).Create(&ns).Error; err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(ns))
+	for _, n := range ns {
+		ids = append(ids, n.ID)
+	}
+	return jobs.Enqueue(tx, deliverNotificationsJob, deliverNotificationsPayload{IDs: ids})
+}
+
+// deliverNotifications publishes stored notifications on their users'
+// event channels. Notifications deleted in the meantime are skipped.
+func (c *NotificationsController) deliverNotifications(_ context.Context, db *gorm.DB, payload json.RawMessage) error {
+	var p deliverNotificationsPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	var ns []models.Notification
+	if err := db.
+		Preload("Actor", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Where("id IN ?", p.IDs).
+		Order("id").
+		Find(&ns).Error; err != nil {
+		return err
+	}
+
+	for _, n := range ns {
+		publishEvent(c.Broker, "notification", types.ToNotificationResponse(n), realtime.UserChannel(n.UserID))
+	}
+	return nil
+}
+
+// mentionNotifications builds one "mention" notification per newly mentioned
+// user.
+func mentionNotifications(userIDs []uint, actorID, postID uint, commentID *uint) []models.Notification {
+	out := make([]models.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		p := postID
+		out = append(out, models.Notification{
+			UserID:    id,
+			Type:      "mention",
+			ActorID:   actorID,
+			PostID:    &p,
+			CommentID: commentID,
+		})
+	}
+	return out
+}
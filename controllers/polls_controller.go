@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 10
+)
+
+// pollRequest is the optional "poll" object accepted by CreatePost.
+type pollRequest struct {
+	Question          string     `json:"question"`
+	Options           []string   `json:"options"`
+	MultipleChoice    bool       `json:"multipleChoice"`
+	ResultsVisibility string     `json:"resultsVisibility"`
+	ClosesAt          *time.Time `json:"closesAt"`
+}
+
+// newPoll validates req and builds the poll to store alongside a new post.
+func newPoll(req pollRequest) (*models.Poll, error) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, errors.New("poll question cannot be empty")
+	}
+	if len([]rune(question)) > 300 {
+		return nil, errors.New("poll question too long (max 300)")
+	}
+
+	if len(req.Options) < minPollOptions || len(req.Options) > maxPollOptions {
+		return nil, errors.New("poll needs 2 to 10 options")
+	}
+	options := make([]models.PollOption, 0, len(req.Options))
+	seen := map[string]bool{}
+	for i, text := range req.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, errors.New("poll options cannot be empty")
+		}
+		if len([]rune(text)) > 200 {
+			return nil, errors.New("poll option too long (max 200)")
+		}
+		if seen[strings.ToLower(text)] {
+			return nil, errors.New("poll options must be unique")
+		}
+		seen[strings.ToLower(text)] = true
+		options = append(options, models.PollOption{Position: i, Text: text})
+	}
+
+	visibility := req.ResultsVisibility
+	if visibility == "" {
+		visibility = "always"
+	}
+	if visibility != "always" && visibility != "after_vote" {
+		return nil, errors.New("resultsVisibility must be always or after_vote")
+	}
+
+	if req.ClosesAt != nil && !req.ClosesAt.After(time.Now()) {
+		return nil, errors.New("closesAt must be in the future")
+	}
+
+	return &models.Poll{
+		Question:          question,
+		MultipleChoice:    req.MultipleChoice,
+		ResultsVisibility: visibility,
+		ClosesAt:          req.ClosesAt,
+		Options:           options,
+	}, nil
+}
+
+type PollsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+}
+
+func NewPollsController(db *gorm.DB, broker realtime.Broker) *PollsController {
+	return &PollsController{DB: db, Broker: broker}
+}
+
+func (c *PollsController) RegisterRoutes(r chi.Router) {
+	r.Post("/posts/{postId}/poll/votes", c.Vote)
+}
+
+// Vote records the caller's ballot. Ballots are final: the unique index on
+// (poll_id, user_id) rejects a second vote even under concurrent requests.
+func (c *PollsController) Vote(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	type voteRequest struct {
+		UserID    uint   `json:"userId"`
+		OptionIDs []uint `json:"optionIds"`
+	}
+
+	var req voteRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var poll models.Poll
+	if err := c.DB.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "topic_id") }).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("post_id = ?", postID).
+		First(&poll).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "poll not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch poll")
+		return
+	}
+
+	if poll.ClosesAt != nil && !time.Now().Before(*poll.ClosesAt) {
+		utils.WriteError(w, http.StatusConflict, "poll is closed")
+		return
+	}
+
+	choices := make([]uint, 0, len(req.OptionIDs))
+	for _, id := range req.OptionIDs {
+		if !slices.Contains(choices, id) {
+			choices = append(choices, id)
+		}
+	}
+	if len(choices) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "optionIds is required")
+		return
+	}
+	if !poll.MultipleChoice && len(choices) > 1 {
+		utils.WriteError(w, http.StatusBadRequest, "poll allows a single choice")
+		return
+	}
+	for _, id := range choices {
+		if !slices.ContainsFunc(poll.Options, func(o models.PollOption) bool { return o.ID == id }) {
+			utils.WriteError(w, http.StatusBadRequest, "option does not belong to this poll")
+			return
+		}
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	if !checkBan(w, c.DB, user.ID, &poll.Post.TopicID) {
+		return
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		ballot := models.PollBallot{PollID: poll.ID, UserID: user.ID}
+		if err := tx.Omit(clause.Associations).Create(&ballot).Error; err != nil {
+			return err
+		}
+		rows := make([]models.PollBallotChoice, 0, len(choices))
+		for _, id := range choices {
+			rows = append(rows, models.PollBallotChoice{BallotID: ballot.ID, OptionID: id})
+		}
+		return tx.Omit(clause.Associations).Create(&rows).Error
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			utils.WriteError(w, http.StatusConflict, "you have already voted")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to record vote")
+		return
+	}
+
+	publishEvent(c.Broker, "poll.voted", map[string]any{
+		"postId": postID,
+		"pollId": poll.ID,
+	}, realtime.PostChannel(postID))
+
+	pr := types.ToPollResponse(poll, time.Now())
+	if err := applyPollState(c.DB, user.ID, []types.PostResponse{{ID: postID, Poll: &pr}}); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch poll")
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, pr)
+}
+
+// applyPollState fills tallies and the viewer's own votes into the polls of
+// posts. Results are shown when the poll is set to always show them, has
+// closed, or the viewer has voted.
+func applyPollState(db *gorm.DB, viewerID uint, posts []types.PostResponse) error {
+	var pollIDs []uint
+	for _, p := range posts {
+		if p.Poll != nil {
+			pollIDs = append(pollIDs, p.Poll.ID)
+		}
+	}
+	if len(pollIDs) == 0 {
+		return nil
+	}
+
+	type optionCount struct {
+		OptionID uint
+		Count    int64
+	}
+	var optionCounts []optionCount
+	if err := db.Model(&models.PollBallotChoice{}).
+		Select("poll_ballot_choices.option_id, COUNT(*) AS count").
+		Joins("JOIN poll_ballots ON poll_ballots.id = poll_ballot_choices.ballot_id").
+		Where("poll_ballots.poll_id IN ?", pollIDs).
+		Group("poll_ballot_choices.option_id").
+		Scan(&optionCounts).Error; err != nil {
+		return err
+	}
+	votes := make(map[uint]int64, len(optionCounts))
+	for _, oc := range optionCounts {
+		votes[oc.OptionID] = oc.Count
+	}
+
+	type pollCount struct {
+		PollID uint
+		Count  int64
+	}
+	var pollCounts []pollCount
+	if err := db.Model(&models.PollBallot{}).
+		Select("poll_id, COUNT(*) AS count").
+		Where("poll_id IN ?", pollIDs).
+		Group("poll_id").
+		Scan(&pollCounts).Error; err != nil {
+		return err
+	}
+	voters := make(map[uint]int64, len(pollCounts))
+	for _, pc := range pollCounts {
+		voters[pc.PollID] = pc.Count
+	}
+
+	mine := map[uint][]uint{}
+	if viewerID != 0 {
+		var ballots []models.PollBallot
+		if err := db.
+			Preload("Choices").
+			Where("user_id = ? AND poll_id IN ?", viewerID, pollIDs).
+			Find(&ballots).Error; err != nil {
+			return err
+		}
+		for _, b := range ballots {
+			ids := make([]uint, 0, len(b.Choices))
+			for _, ch := range b.Choices {
+				ids = append(ids, ch.OptionID)
+			}
+			mine[b.PollID] = ids
+		}
+	}
+
+	for i := range posts {
+		poll := posts[i].Poll
+		if poll == nil {
+			continue
+		}
+
+		myVotes, voted := mine[poll.ID]
+		if viewerID != 0 {
+			poll.Voted = &voted
+			poll.MyVotes = myVotes
+		}
+
+		poll.ResultsVisible = poll.ResultsVisibility == "always" || poll.Closed || voted
+		if !poll.ResultsVisible {
+			continue
+		}
+		total := voters[poll.ID]
+		poll.TotalVoters = &total
+		for j := range poll.Options {
+			n := votes[poll.Options[j].ID]
+			poll.Options[j].Votes = &n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostReadsController struct {
+	DB *gorm.DB
+}
+
+func NewPostReadsController(db *gorm.DB) *PostReadsController {
+	return &PostReadsController{DB: db}
+}
+
+func (c *PostReadsController) RegisterRoutes(r chi.Router) {
+	r.Post("/posts/{postId}/read", c.MarkRead)
+}
+
+// MarkRead moves the caller's read marker on a post forward to commentId,
+// or to the latest comment when it is omitted. Markers never move
+// backwards. GetCommentsByPost also marks what it returns as read.
+func (c *PostReadsController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	type markReadRequest struct {
+		UserID    uint `json:"userId"`
+		CommentID uint `json:"commentId"`
+	}
+
+	var req markReadRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	var post models.Post
+	if err := c.DB.Select("id").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	var upTo *uint
+	var comment models.Comment
+	dbq := c.DB.Select("id").Where("post_id = ?", post.ID)
+	if req.CommentID != 0 {
+		dbq = dbq.Where("id = ?", req.CommentID)
+	}
+	err = dbq.Order("id DESC").First(&comment).Error
+	switch {
+	case err == nil:
+		upTo = &comment.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch comment")
+		return
+	case req.CommentID != 0:
+		utils.WriteError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+
+	if err := markPostRead(c.DB, user.ID, post.ID, upTo); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update read marker")
+		return
+	}
+
+	var read models.PostRead
+	if err := c.DB.Where("user_id = ? AND post_id = ?", user.ID, post.ID).First(&read).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch read marker")
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.ToPostReadResponse(read))
+}
+
+// markPostRead records that userID has read postID up to commentID (nil
+// when the post has no comments yet), keeping the later of the stored and
+// new markers.
+func markPostRead(db *gorm.DB, userID, postID uint, commentID *uint) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_read_comment_id": gorm.Expr("GREATEST(post_reads.last_read_comment_id, excluded.last_read_comment_id)"),
+			"read_at":              gorm.Expr("excluded.read_at"),
+		}),
+	}).Create(&models.PostRead{
+		UserID:            userID,
+		PostID:            postID,
+		LastReadCommentID: commentID,
+		ReadAt:            time.Now(),
+	}).Error
+}
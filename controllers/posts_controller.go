@@ -4,30 +4,42 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"CVWO-Backend/markdown"
 	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/storage"
 	"CVWO-Backend/types"
 	"CVWO-Backend/utils"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostsController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Broker realtime.Broker
+	Signer *storage.URLSigner
 }
 
-func NewPostsController(db *gorm.DB) *PostsController {
-	return &PostsController{DB: db}
+func NewPostsController(db *gorm.DB, broker realtime.Broker, signer *storage.URLSigner) *PostsController {
+	return &PostsController{DB: db, Broker: broker, Signer: signer}
 }
 
 func (c *PostsController) RegisterRoutes(r chi.Router) {
 	r.Get("/topics/{topicId}/posts", c.GetPostsByTopic)
 	r.Post("/topics/{topicId}/posts", c.CreatePost)
 	
+	r.Get("/posts", c.SearchPosts)
 	r.Get("/posts/{postId}", c.GetPostByID)
 	r.Patch("/posts/{postId}", c.UpdatePost)
 	r.Delete("/posts/{postId}", c.DeletePost)
+
+	r.Patch("/posts/{postId}/pin", c.SetPinned)
+	r.Patch("/posts/{postId}/lock", c.SetLocked)
+	r.Patch("/posts/{postId}/accepted-answer", c.SetAcceptedAnswer)
 }
 
 func (c *PostsController) GetPostsByTopic(w http.ResponseWriter, r *http.Request) {
@@ -47,18 +59,10 @@ func (c *PostsController) GetPostsByTopic(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	q := strings.TrimSpace(r.URL.Query().Get("q"))
-
-	dbq := c.DB.
-		Where("topic_id = ?", topicID).
-		Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "username")
-		}).
-		Order("created_at DESC")
-
-	if q != "" {
-		like := "%" + q + "%"
-		dbq = dbq.Where("(title ILIKE ? OR body ILIKE ?)", like, like)
+	dbq, err := topicPostsQuery(c.DB, topicID, r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	var posts []models.Post
@@ -69,7 +73,11 @@ func (c *PostsController) GetPostsByTopic(w http.ResponseWriter, r *http.Request
 
 	out := make([]types.PostResponse, 0, len(posts))
 	for _, p := range posts {
-		out = append(out, types.ToPostResponse(p))
+		out = append(out, types.ToPostResponse(p, c.Signer))
+	}
+	if err := applyViewerState(c.DB, viewerID(r), out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch posts")
+		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, out)
@@ -93,9 +101,11 @@ func (c *PostsController) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type createPostRequest struct {
-		UserID uint   `json:"userId"`
-		Title  string `json:"title"`
-		Body   string `json:"body"`
+		UserID uint         `json:"userId"`
+		Title  string       `json:"title"`
+		Body   string       `json:"body"`
+		Tags   []string     `json:"tags"`
+		Poll   *pollRequest `json:"poll"`
 	}
 	var req createPostRequest
 	if err := utils.DecodeJSON(r, &req); err != nil {
@@ -103,23 +113,19 @@ func (c *PostsController) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.Title = strings.TrimSpace(req.Title)
-	req.Body = strings.TrimSpace(req.Body)
-
 	if req.UserID == 0 {
 		utils.WriteError(w, http.StatusBadRequest, "userId is required")
 		return
 	}
-	if req.Title == "" {
-		utils.WriteError(w, http.StatusBadRequest, "title cannot be empty")
-		return
-	}
-	if len(req.Title) > 120 {
-		utils.WriteError(w, http.StatusBadRequest, "title too long (max 120)")
-		return
+	in := newPost{
+		TopicID: topicID,
+		Title:   req.Title,
+		Body:    req.Body,
+		Tags:    req.Tags,
+		Poll:    req.Poll,
 	}
-	if req.Body == "" {
-		utils.WriteError(w, http.StatusBadRequest, "body cannot be empty")
+	if err := in.validate(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -133,21 +139,133 @@ func (c *PostsController) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post := models.Post{
-		TopicID: topicID,
-		UserID:  req.UserID,
-		Title:   req.Title,
-		Body:    req.Body,
+	if !checkBan(w, c.DB, user.ID, &topicID) {
+		return
 	}
 
-	if err := c.DB.Create(&post).Error; err != nil {
+	var post models.Post
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		post, err = insertPost(tx, user, in)
+		return err
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to create post")
 		return
 	}
 
+	resp := publishPostCreated(c.DB, c.Broker, c.Signer, post)
+
+	out := []types.PostResponse{resp}
+	if err := applyViewerState(c.DB, user.ID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, out[0])
+}
+
+// newPost is a post waiting to be created, either from CreatePost or from a
+// scheduled draft. Both go through validate, insertPost and
+// publishPostCreated so drafts publish exactly like a direct post.
+type newPost struct {
+	TopicID uint
+	Title   string
+	Body    string
+	Tags    []string
+	Poll    *pollRequest
+
+	tagNames []string
+	poll     *models.Poll
+}
+
+// validate trims and checks the fields; its errors are safe to show to the
+// author.
+func (p *newPost) validate() error {
+	p.Title = strings.TrimSpace(p.Title)
+	p.Body = strings.TrimSpace(p.Body)
+
+	if p.Title == "" {
+		return errors.New("title cannot be empty")
+	}
+	if len(p.Title) > 120 {
+		return errors.New("title too long (max 120)")
+	}
+	if p.Body == "" {
+		return errors.New("body cannot be empty")
+	}
+	tagNames, err := normalizeTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	p.tagNames = tagNames
+	if p.Poll != nil {
+		if p.poll, err = newPoll(*p.Poll); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertPost creates a validated post with its tags, poll, mentions and
+// mention notifications in tx.
+func insertPost(tx *gorm.DB, user models.User, p newPost) (models.Post, error) {
+	post := models.Post{
+		TopicID:      p.TopicID,
+		UserID:       user.ID,
+		Title:        p.Title,
+		Body:         p.Body,
+		RenderedBody: markdown.Render(p.Body),
+	}
+
+	if err := tx.Create(&post).Error; err != nil {
+		return post, err
+	}
+	if err := countPostCreated(tx, post.TopicID, post.CreatedAt); err != nil {
+		return post, err
+	}
+
+	if len(p.tagNames) > 0 {
+		tags, err := upsertTags(tx, p.tagNames)
+		if err != nil {
+			return post, err
+		}
+		if err := tx.Model(&post).Association("Tags").Replace(tags); err != nil {
+			return post, err
+		}
+		post.Tags = tags
+	}
+
+	if p.poll != nil {
+		poll := *p.poll
+		poll.PostID = post.ID
+		if err := tx.Create(&poll).Error; err != nil {
+			return post, err
+		}
+		post.Poll = &poll
+	}
+
+	mentions, added, err := syncMentions(tx, user.ID, post.ID, nil, post.Body)
+	if err != nil {
+		return post, err
+	}
+	post.Mentions = mentions
+
+	notifications := mentionNotifications(added, user.ID, post.ID, nil)
+	if err := createNotifications(tx, notifications); err != nil {
+		return post, err
+	}
 	post.User = user
+	return post, nil
+}
 
-	utils.WriteJSON(w, http.StatusCreated, types.ToPostResponse(post))
+// publishPostCreated pushes post.created to subscribers and webhooks once
+// the post's transaction has committed.
+func publishPostCreated(db *gorm.DB, b realtime.Broker, signer *storage.URLSigner, post models.Post) types.PostResponse {
+	resp := types.ToPostResponse(post, signer)
+	publishEvent(b, "post.created", resp, realtime.TopicChannel(post.TopicID))
+	queueWebhooks(db, "post.created", resp)
+	return resp
 }
 
 func (c *PostsController) UpdatePost(w http.ResponseWriter, r *http.Request) {
@@ -158,9 +276,10 @@ func (c *PostsController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type updatePostRequest struct {
-		UserID uint    `json:"userId"`
-		Title  *string `json:"title,omitempty"`
-		Body   *string `json:"body,omitempty"`
+		UserID uint      `json:"userId"`
+		Title  *string   `json:"title,omitempty"`
+		Body   *string   `json:"body,omitempty"`
+		Tags   *[]string `json:"tags,omitempty"`
 	}
 	var req updatePostRequest
 	if err := utils.DecodeJSON(r, &req); err != nil {
@@ -171,7 +290,7 @@ func (c *PostsController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, "userId is required")
 		return
 	}
-	if req.Title == nil && req.Body == nil {
+	if req.Title == nil && req.Body == nil && req.Tags == nil {
 		utils.WriteError(w, http.StatusBadRequest, "nothing to update")
 		return
 	}
@@ -197,9 +316,16 @@ func (c *PostsController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	owner := post.UserID == requester.ID
-	if !(isPrivileged(requester) || owner) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden")
-		return
+	if !owner {
+		allowed, err := canModerateTopic(c.DB, requester, post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
 	}
 
 	updates := map[string]any{}
@@ -222,22 +348,75 @@ func (c *PostsController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		updates["body"] = b
+		updates["rendered_body"] = markdown.Render(b)
+	}
+	if len(updates) > 0 {
+		updates["edited_at"] = time.Now()
+	}
+
+	var tagNames []string
+	if req.Tags != nil {
+		tagNames, err = normalizeTags(*req.Tags)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
-	if err := c.DB.Model(&post).Updates(updates).Error; err != nil {
+	before := post
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Tags != nil {
+			tags, err := upsertTags(tx, tagNames)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&post).Association("Tags").Replace(tags); err != nil {
+				return err
+			}
+		}
+		if req.Body != nil {
+			_, added, err := syncMentions(tx, post.UserID, post.ID, nil, post.Body)
+			if err != nil {
+				return err
+			}
+			notifications := mentionNotifications(added, post.UserID, post.ID, nil)
+			if err := createNotifications(tx, notifications); err != nil {
+				return err
+			}
+		}
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "post.update", "post", post.ID, before, post)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to update post")
 		return
 	}
 
 	var updated models.Post
 	if err := c.DB.
-		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Scopes(preloadPostDetails).
 		First(&updated, postID).Error; err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.ToPostResponse(updated))
+	resp := types.ToPostResponse(updated, c.Signer)
+	publishEvent(c.Broker, "post.updated", resp,
+		realtime.PostChannel(updated.ID), realtime.TopicChannel(updated.TopicID))
+	queueWebhooks(c.DB, "post.updated", resp)
+
+	out := []types.PostResponse{resp}
+	if err := applyViewerState(c.DB, requester.ID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out[0])
 }
 
 func (c *PostsController) DeletePost(w http.ResponseWriter, r *http.Request) {
@@ -281,16 +460,43 @@ func (c *PostsController) DeletePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	owner := post.UserID == requester.ID
-	if !(isPrivileged(requester) || owner) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden")
-		return
+	if !owner {
+		allowed, err := canModerateTopic(c.DB, requester, post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
 	}
 
-	if err := c.DB.Delete(&post).Error; err != nil {
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := queuePostAttachmentCleanup(tx, post.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		if err := countPostDeleted(tx, post.TopicID); err != nil {
+			return err
+		}
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "post.delete", "post", post.ID, post, nil)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to delete post")
 		return
 	}
 
+	deleted := map[string]any{"id": post.ID, "topicId": post.TopicID}
+	publishEvent(c.Broker, "post.deleted", deleted,
+		realtime.PostChannel(post.ID), realtime.TopicChannel(post.TopicID))
+	queueWebhooks(c.DB, "post.deleted", deleted)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -303,9 +509,7 @@ func (c *PostsController) GetPostByID(w http.ResponseWriter, r *http.Request) {
 
 	var post models.Post
 	if err := c.DB.
-		Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "username")
-		}).
+		Scopes(preloadPostDetails).
 		First(&post, postID).Error; err != nil {
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -316,5 +520,415 @@ func (c *PostsController) GetPostByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.ToPostResponse(post))
+	out := []types.PostResponse{types.ToPostResponse(post, c.Signer)}
+	if err := applyViewerState(c.DB, viewerID(r), out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out[0])
+}
+
+func (c *PostsController) SetPinned(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	type setPinnedRequest struct {
+		UserID uint  `json:"userId"`
+		Pinned *bool `json:"pinned"`
+	}
+	var req setPinnedRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.Pinned == nil {
+		utils.WriteError(w, http.StatusBadRequest, "pinned is required")
+		return
+	}
+
+	updates := map[string]any{"pinned": *req.Pinned, "pinned_at": nil}
+	if *req.Pinned {
+		updates["pinned_at"] = time.Now()
+	}
+
+	action := "post.unpin"
+	if *req.Pinned {
+		action = "post.pin"
+	}
+	c.moderatePost(w, r, postID, req.UserID, action, updates)
+}
+
+// SetAcceptedAnswer marks one comment as the answer to a post in a Q&A
+// topic, or clears it when commentId is null. The post author and topic
+// moderators may do this.
+func (c *PostsController) SetAcceptedAnswer(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	type acceptAnswerRequest struct {
+		UserID    uint  `json:"userId"`
+		CommentID *uint `json:"commentId"`
+	}
+	var req acceptAnswerRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var post models.Post
+	if err := c.DB.Preload("Topic").First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+	if req.CommentID != nil && !post.Topic.QAMode {
+		utils.WriteError(w, http.StatusConflict, "topic is not in Q&A mode")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "username", "role").First(&requester, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	owner := post.UserID == requester.ID
+	if !owner {
+		allowed, err := canModerateTopic(c.DB, requester, post.TopicID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+			return
+		}
+		if !allowed {
+			utils.WriteError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+	}
+
+	var comment models.Comment
+	if req.CommentID != nil {
+		if err := c.DB.Select("id", "post_id", "user_id").
+			Where("post_id = ?", post.ID).
+			First(&comment, *req.CommentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "comment not found on this post")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch comment")
+			return
+		}
+	}
+
+	updates := map[string]any{"accepted_comment_id": nil, "accepted_at": nil}
+	action := "post.unaccept_answer"
+	if req.CommentID != nil {
+		updates = map[string]any{"accepted_comment_id": comment.ID, "accepted_at": time.Now()}
+		action = "post.accept_answer"
+	}
+
+	before := post
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Omit(clause.Associations).Updates(updates).Error; err != nil {
+			return err
+		}
+		newlyAccepted := req.CommentID != nil &&
+			(before.AcceptedCommentID == nil || *before.AcceptedCommentID != comment.ID)
+		if newlyAccepted && comment.UserID != requester.ID {
+			notifications := []models.Notification{{
+				UserID:    comment.UserID,
+				Type:      "answer_accepted",
+				ActorID:   requester.ID,
+				PostID:    &post.ID,
+				CommentID: &comment.ID,
+			}}
+			if err := createNotifications(tx, notifications); err != nil {
+				return err
+			}
+		}
+		if owner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, action, "post", post.ID, before, post)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update post")
+		return
+	}
+
+	var updated models.Post
+	if err := c.DB.
+		Scopes(preloadPostDetails).
+		First(&updated, postID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
+		return
+	}
+
+	resp := types.ToPostResponse(updated, c.Signer)
+	publishEvent(c.Broker, "post.updated", resp,
+		realtime.PostChannel(updated.ID), realtime.TopicChannel(updated.TopicID))
+	queueWebhooks(c.DB, "post.updated", resp)
+
+	out := []types.PostResponse{resp}
+	if err := applyViewerState(c.DB, requester.ID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out[0])
+}
+
+func (c *PostsController) SetLocked(w http.ResponseWriter, r *http.Request) {
+	postID, err := utils.ParseUintParam(r, "postId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid postId")
+		return
+	}
+
+	type setLockedRequest struct {
+		UserID uint   `json:"userId"`
+		Locked *bool  `json:"locked"`
+		Reason string `json:"reason"`
+	}
+	var req setLockedRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.Locked == nil {
+		utils.WriteError(w, http.StatusBadRequest, "locked is required")
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 200 {
+		utils.WriteError(w, http.StatusBadRequest, "reason too long (max 200)")
+		return
+	}
+
+	updates := map[string]any{"locked": false, "lock_reason": "", "locked_at": nil}
+	action := "post.unlock"
+	if *req.Locked {
+		updates = map[string]any{"locked": true, "lock_reason": reason, "locked_at": time.Now()}
+		action = "post.lock"
+	}
+	c.moderatePost(w, r, postID, req.UserID, action, updates)
+}
+
+// moderatePost applies a moderator-only change to a post, records it in the
+// audit log and pushes the updated post to subscribers.
+func (c *PostsController) moderatePost(w http.ResponseWriter, r *http.Request, postID, userID uint, action string, updates map[string]any) {
+	var post models.Post
+	if err := c.DB.First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "post not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch post")
+		return
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	allowed, err := canModerateTopic(c.DB, requester, post.TopicID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking permissions")
+		return
+	}
+	if !allowed {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	before := post
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Updates(updates).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, action, "post", post.ID, before, post)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update post")
+		return
+	}
+
+	var updated models.Post
+	if err := c.DB.
+		Scopes(preloadPostDetails).
+		First(&updated, postID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
+		return
+	}
+
+	resp := types.ToPostResponse(updated, c.Signer)
+	publishEvent(c.Broker, "post.updated", resp,
+		realtime.PostChannel(updated.ID), realtime.TopicChannel(updated.TopicID))
+	queueWebhooks(c.DB, "post.updated", resp)
+
+	out := []types.PostResponse{resp}
+	if err := applyViewerState(c.DB, requester.ID, out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated post")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out[0])
+}
+
+// SearchPosts searches every topic. It accepts the same q and tag filters as
+// GetPostsByTopic and is paged since results aren't bounded by a topic.
+func (c *PostsController) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Post{}).Scopes(filterPostsByTags(r.URL.Query()["tag"]), filterSolved(r.URL.Query().Get("solved")))
+	if q != "" {
+		like := "%" + q + "%"
+		dbq = dbq.Where("(title ILIKE ? OR body ILIKE ?)", like, like)
+	}
+	if !collapseMuted(r) {
+		dbq = dbq.Scopes(hideMutedAuthors("posts.user_id", viewerID(r)))
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count posts")
+		return
+	}
+
+	var posts []models.Post
+	if err := dbq.
+		Scopes(preloadPostDetails).
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&posts).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch posts")
+		return
+	}
+
+	out := make([]types.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		out = append(out, types.ToPostResponse(p, c.Signer))
+	}
+	if err := applyViewerState(c.DB, viewerID(r), out); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch posts")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.PostResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// topicPostSorts maps the sort values GetPostsByTopic accepts to the order
+// applied after pinned posts. Each one is backed by an index from
+// db.EnsurePostSortIndexes and ends in id, so posts with equal timestamps
+// keep their place from page to page. "activity" bumps a post with every
+// new comment.
+var topicPostSorts = map[string]string{
+	"new":      "created_at DESC, id DESC",
+	"oldest":   "created_at ASC, id ASC",
+	"activity": "COALESCE(last_comment_at, created_at) DESC, id DESC",
+	"comments": "comment_count DESC, created_at DESC, id DESC",
+}
+
+// topicPostsQuery is the query behind GetPostsByTopic, shared with the
+// topic's syndication feeds so both list the same posts in the same order.
+// The only error is an unknown sort.
+func topicPostsQuery(db *gorm.DB, topicID uint, r *http.Request) (*gorm.DB, error) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "new"
+	}
+	order, ok := topicPostSorts[sort]
+	if !ok {
+		return nil, errors.New("sort must be new, activity, comments or oldest")
+	}
+
+	dbq := db.
+		Where("topic_id = ?", topicID).
+		Scopes(preloadPostDetails, filterPostsByTags(r.URL.Query()["tag"]), filterSolved(r.URL.Query().Get("solved"))).
+		Order("pinned DESC").
+		Order(order)
+
+	if q != "" {
+		like := "%" + q + "%"
+		dbq = dbq.Where("(title ILIKE ? OR body ILIKE ?)", like, like)
+	}
+	if !collapseMuted(r) {
+		dbq = dbq.Scopes(hideMutedAuthors("posts.user_id", viewerID(r)))
+	}
+	return dbq, nil
+}
+
+// preloadPostDetails loads everything ToPostResponse needs.
+func preloadPostDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Preload("Mentions", func(db *gorm.DB) *gorm.DB { return db.Order("start ASC") }).
+		Preload("Mentions.MentionedUser", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Preload("Attachments", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Poll").
+		Preload("Poll.Options", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") })
+}
+
+// filterSolved narrows posts to solved (accepted answer) or unsolved ones
+// for solved=true or solved=false; any other value leaves the query as is.
+func filterSolved(solved string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch solved {
+		case "true":
+			return db.Where("posts.accepted_comment_id IS NOT NULL")
+		case "false":
+			return db.Where("posts.accepted_comment_id IS NULL")
+		}
+		return db
+	}
+}
+
+// clearAcceptedAnswer unsets commentID as an accepted answer; call it
+// whenever a comment is deleted.
+func clearAcceptedAnswer(tx *gorm.DB, commentID uint) error {
+	return tx.Model(&models.Post{}).
+		Where("accepted_comment_id = ?", commentID).
+		Updates(map[string]any{"accepted_comment_id": nil, "accepted_at": nil}).Error
 }
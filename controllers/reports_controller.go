@@ -0,0 +1,615 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var reportTargetTypes = []string{"post", "comment", "user", "conversation"}
+
+var reportReasons = []string{"spam", "harassment", "hate", "nsfw", "misinformation", "other"}
+
+type ReportsController struct {
+	DB     *gorm.DB
+	Broker realtime.Broker
+}
+
+func NewReportsController(db *gorm.DB, broker realtime.Broker) *ReportsController {
+	return &ReportsController{DB: db, Broker: broker}
+}
+
+func (c *ReportsController) RegisterRoutes(r chi.Router) {
+	r.Post("/reports", c.CreateReport)
+
+	r.Get("/moderation/reports", c.GetReportQueue)
+	r.Get("/moderation/reports/{reportId}", c.GetReport)
+	r.Post("/moderation/reports/{reportId}/resolve", c.ResolveReport)
+	r.Post("/moderation/reports/{reportId}/dismiss", c.DismissReport)
+	r.Post("/moderation/reports/{reportId}/action", c.TakeAction)
+	r.Get("/moderation/log", c.GetModerationLog)
+}
+
+func (c *ReportsController) CreateReport(w http.ResponseWriter, r *http.Request) {
+	type createReportRequest struct {
+		UserID     uint   `json:"userId"`
+		TargetType string `json:"targetType"`
+		TargetID   uint   `json:"targetId"`
+		Reason     string `json:"reason"`
+		Details    string `json:"details"`
+	}
+
+	var req createReportRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	req.Details = strings.TrimSpace(req.Details)
+
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if !slices.Contains(reportTargetTypes, req.TargetType) {
+		utils.WriteError(w, http.StatusBadRequest, "targetType must be one of: "+strings.Join(reportTargetTypes, ", "))
+		return
+	}
+	if req.TargetID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "targetId is required")
+		return
+	}
+	if !slices.Contains(reportReasons, req.Reason) {
+		utils.WriteError(w, http.StatusBadRequest, "reason must be one of: "+strings.Join(reportReasons, ", "))
+		return
+	}
+	if len(req.Details) > 1000 {
+		utils.WriteError(w, http.StatusBadRequest, "details too long (max 1000)")
+		return
+	}
+	if req.TargetType == "user" && req.TargetID == req.UserID {
+		utils.WriteError(w, http.StatusBadRequest, "cannot report yourself")
+		return
+	}
+
+	var reporter models.User
+	if err := c.DB.Select("id").First(&reporter, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	exists, err := reportTargetExists(c.DB, req.TargetType, req.TargetID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking target")
+		return
+	}
+	if !exists {
+		utils.WriteError(w, http.StatusNotFound, req.TargetType+" not found")
+		return
+	}
+
+	// Reporting a conversation lets admins read it, so only its participants
+	// may do so.
+	if req.TargetType == "conversation" {
+		ok, err := isParticipant(c.DB, req.TargetID, reporter.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "db error checking participants")
+			return
+		}
+		if !ok {
+			utils.WriteError(w, http.StatusNotFound, "conversation not found")
+			return
+		}
+	}
+
+	var report models.Report
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent first reports race on the partial unique index; whoever
+		// loses simply joins the report the winner opened.
+		report = models.Report{TargetType: req.TargetType, TargetID: req.TargetID, Status: "open"}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "target_type"}, {Name: "target_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'open'"}}},
+			DoNothing:   true,
+		}).Create(&report).Error; err != nil {
+			return err
+		}
+		if report.ID == 0 {
+			if err := tx.
+				Where("target_type = ? AND target_id = ? AND status = ?", req.TargetType, req.TargetID, "open").
+				First(&report).Error; err != nil {
+				return err
+			}
+		}
+
+		entry := models.ReportEntry{
+			ReportID:   report.ID,
+			ReporterID: req.UserID,
+			Reason:     req.Reason,
+			Details:    req.Details,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&report).Updates(map[string]any{
+			"report_count":     gorm.Expr("report_count + 1"),
+			"last_reported_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			utils.WriteError(w, http.StatusConflict, "you have already reported this")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to create report")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]any{
+		"message":  "report received",
+		"reportId": report.ID,
+	})
+}
+
+func (c *ReportsController) GetReportQueue(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.requireModerator(w, r); !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.Report{}).Where("status = ?", status)
+	if t := r.URL.Query().Get("targetType"); t != "" {
+		dbq = dbq.Where("target_type = ?", t)
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count reports")
+		return
+	}
+
+	var reports []models.Report
+	if err := dbq.
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Entries.Reporter", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("report_count DESC").
+		Order("created_at ASC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&reports).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch reports")
+		return
+	}
+
+	previews, err := reportPreviews(c.DB, reports)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch report targets")
+		return
+	}
+	out := make([]types.ReportResponse, 0, len(reports))
+	for _, rp := range reports {
+		out = append(out, types.ToReportResponse(rp, previews[rp.TargetType][rp.TargetID]))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.ReportResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+func (c *ReportsController) GetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := utils.ParseUintParam(r, "reportId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid reportId")
+		return
+	}
+	if _, ok := c.requireModerator(w, r); !ok {
+		return
+	}
+
+	var report models.Report
+	if err := c.DB.
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Entries.Reporter", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "report not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch report")
+		return
+	}
+
+	previews, err := reportPreviews(c.DB, []models.Report{report})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch report target")
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.ToReportResponse(report, previews[report.TargetType][report.TargetID]))
+}
+
+func (c *ReportsController) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	c.closeReport(w, r, "resolved", "resolve")
+}
+
+func (c *ReportsController) DismissReport(w http.ResponseWriter, r *http.Request) {
+	c.closeReport(w, r, "dismissed", "dismiss")
+}
+
+// closeReport resolves or dismisses a report without touching its target.
+func (c *ReportsController) closeReport(w http.ResponseWriter, r *http.Request, status, action string) {
+	reportID, err := utils.ParseUintParam(r, "reportId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid reportId")
+		return
+	}
+
+	type closeReportRequest struct {
+		UserID uint   `json:"userId"`
+		Note   string `json:"note"`
+	}
+
+	var req closeReportRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	moderator, ok := c.loadModerator(w, req.UserID)
+	if !ok {
+		return
+	}
+
+	report, ok := c.loadOpenReport(w, reportID)
+	if !ok {
+		return
+	}
+
+	note := strings.TrimSpace(req.Note)
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		return closeReportTx(tx, &report, moderator.ID, status, action, note)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update report")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToReportResponse(report, ""))
+}
+
+// TakeAction acts on the reported content and closes the report. The only
+// action currently available is deleting a reported post or comment.
+func (c *ReportsController) TakeAction(w http.ResponseWriter, r *http.Request) {
+	reportID, err := utils.ParseUintParam(r, "reportId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid reportId")
+		return
+	}
+
+	type takeActionRequest struct {
+		UserID uint   `json:"userId"`
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+
+	var req takeActionRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	moderator, ok := c.loadModerator(w, req.UserID)
+	if !ok {
+		return
+	}
+
+	report, ok := c.loadOpenReport(w, reportID)
+	if !ok {
+		return
+	}
+
+	if req.Action != "delete" {
+		utils.WriteError(w, http.StatusBadRequest, "action must be: delete")
+		return
+	}
+	if report.TargetType != "post" && report.TargetType != "comment" {
+		utils.WriteError(w, http.StatusBadRequest, "delete is only available for posts and comments")
+		return
+	}
+
+	note := strings.TrimSpace(req.Note)
+	var deletedPost models.Post
+	var deletedComment models.Comment
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		switch report.TargetType {
+		case "post":
+			if err := tx.First(&deletedPost, report.TargetID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err := queuePostAttachmentCleanup(tx, report.TargetID); err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Post{}, report.TargetID).Error; err != nil {
+				return err
+			}
+			if deletedPost.ID != 0 {
+				if err := countPostDeleted(tx, deletedPost.TopicID); err != nil {
+					return err
+				}
+			}
+			if err := writeAudit(tx, r, moderator.ID, "post.delete", "post", report.TargetID, deletedPost, nil); err != nil {
+				return err
+			}
+		case "comment":
+			if err := tx.First(&deletedComment, report.TargetID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err := queueCommentAttachmentCleanup(tx, report.TargetID); err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Comment{}, report.TargetID).Error; err != nil {
+				return err
+			}
+			if deletedComment.ID != 0 {
+				if err := countCommentDeleted(tx, deletedComment.PostID); err != nil {
+					return err
+				}
+			}
+			if err := clearAcceptedAnswer(tx, report.TargetID); err != nil {
+				return err
+			}
+			if err := writeAudit(tx, r, moderator.ID, "comment.delete", "comment", report.TargetID, deletedComment, nil); err != nil {
+				return err
+			}
+		}
+		return closeReportTx(tx, &report, moderator.ID, "actioned", "delete_"+report.TargetType, note)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to take action")
+		return
+	}
+
+	if deletedPost.ID != 0 {
+		deleted := map[string]any{"id": deletedPost.ID, "topicId": deletedPost.TopicID}
+		publishEvent(c.Broker, "post.deleted", deleted,
+			realtime.PostChannel(deletedPost.ID), realtime.TopicChannel(deletedPost.TopicID))
+		queueWebhooks(c.DB, "post.deleted", deleted)
+	}
+	if deletedComment.ID != 0 {
+		deleted := map[string]any{"id": deletedComment.ID, "postId": deletedComment.PostID}
+		publishEvent(c.Broker, "comment.deleted", deleted, realtime.PostChannel(deletedComment.PostID))
+		queueWebhooks(c.DB, "comment.deleted", deleted)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToReportResponse(report, ""))
+}
+
+func (c *ReportsController) GetModerationLog(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.requireModerator(w, r); !ok {
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.ModerationLog{})
+	if reportID, err := utils.ParseUintQuery(r, "reportId"); err == nil {
+		dbq = dbq.Where("report_id = ?", reportID)
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count log entries")
+		return
+	}
+
+	var entries []models.ModerationLog
+	if err := dbq.
+		Preload("Moderator", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&entries).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch log entries")
+		return
+	}
+
+	out := make([]types.ModerationLogResponse, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, types.ToModerationLogResponse(e))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.ModerationLogResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// requireModerator checks the userId query parameter used by the read-only
+// moderation endpoints.
+func (c *ReportsController) requireModerator(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return models.User{}, false
+	}
+	return c.loadModerator(w, userID)
+}
+
+func (c *ReportsController) loadModerator(w http.ResponseWriter, userID uint) (models.User, bool) {
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return models.User{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return models.User{}, false
+	}
+	if !isPrivileged(requester) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return models.User{}, false
+	}
+	return requester, true
+}
+
+func (c *ReportsController) loadOpenReport(w http.ResponseWriter, reportID uint) (models.Report, bool) {
+	var report models.Report
+	if err := c.DB.First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "report not found")
+			return report, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch report")
+		return report, false
+	}
+	if report.Status != "open" {
+		utils.WriteError(w, http.StatusConflict, "report already "+report.Status)
+		return report, false
+	}
+	return report, true
+}
+
+func closeReportTx(tx *gorm.DB, report *models.Report, moderatorID uint, status, action, note string) error {
+	now := time.Now()
+	if err := tx.Model(report).Updates(map[string]any{
+		"status":              status,
+		"resolved_by_user_id": moderatorID,
+		"resolved_at":         &now,
+		"resolution_note":     note,
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ModerationLog{
+		ModeratorID: moderatorID,
+		ReportID:    &report.ID,
+		Action:      action,
+		TargetType:  report.TargetType,
+		TargetID:    report.TargetID,
+		Note:        note,
+	}).Error
+}
+
+func reportTargetExists(db *gorm.DB, targetType string, targetID uint) (bool, error) {
+	var model any
+	switch targetType {
+	case "post":
+		model = &models.Post{}
+	case "comment":
+		model = &models.Comment{}
+	case "user":
+		model = &models.User{}
+	case "conversation":
+		model = &models.Conversation{}
+	default:
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(model).Where("id = ?", targetID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// reportPreviews loads a short description of each report target, grouped by
+// target type, so the queue can be triaged without opening every item.
+func reportPreviews(db *gorm.DB, reports []models.Report) (map[string]map[uint]string, error) {
+	ids := map[string][]uint{}
+	for _, r := range reports {
+		ids[r.TargetType] = append(ids[r.TargetType], r.TargetID)
+	}
+
+	out := map[string]map[uint]string{"post": {}, "comment": {}, "user": {}, "conversation": {}}
+
+	if len(ids["post"]) > 0 {
+		var posts []models.Post
+		if err := db.Select("id", "title").Where("id IN ?", ids["post"]).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			out["post"][p.ID] = p.Title
+		}
+	}
+	if len(ids["comment"]) > 0 {
+		var comments []models.Comment
+		if err := db.Select("id", "body").Where("id IN ?", ids["comment"]).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		for _, cm := range comments {
+			out["comment"][cm.ID] = truncate(cm.Body, 140)
+		}
+	}
+	if len(ids["user"]) > 0 {
+		var users []models.User
+		if err := db.Select("id", "username").Where("id IN ?", ids["user"]).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			out["user"][u.ID] = u.Username
+		}
+	}
+	// Conversations are previewed by participants only; message bodies stay
+	// private until an admin opens the conversation.
+	if len(ids["conversation"]) > 0 {
+		var participants []models.ConversationParticipant
+		if err := db.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+			Where("conversation_id IN ?", ids["conversation"]).
+			Order("created_at ASC, user_id ASC").
+			Find(&participants).Error; err != nil {
+			return nil, err
+		}
+		names := map[uint][]string{}
+		for _, p := range participants {
+			names[p.ConversationID] = append(names[p.ConversationID], p.User.Username)
+		}
+		for id, ns := range names {
+			out["conversation"][id] = "between " + strings.Join(ns, ", ")
+		}
+	}
+
+	return out, nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -1,7 +1,50 @@
 package controllers
 
-import "CVWO-Backend/models"
+import (
+	"errors"
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/utils"
+
+	"gorm.io/gorm"
+)
 
 func isPrivileged(u models.User) bool {
 	return u.Role == "admin" || u.Role == "moderator"
 }
+
+// canModerateTopic reports whether u may edit or delete other users' content
+// in topicID: global admins and moderators everywhere, otherwise only users
+// assigned as moderators of that topic.
+func canModerateTopic(db *gorm.DB, u models.User, topicID uint) (bool, error) {
+	if isPrivileged(u) {
+		return true, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.TopicModerator{}).
+		Where("topic_id = ? AND user_id = ?", topicID, u.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// requireAdmin loads userID and writes an error unless they are an admin.
+func requireAdmin(w http.ResponseWriter, db *gorm.DB, userID uint) (models.User, bool) {
+	var requester models.User
+	if err := db.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return requester, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return requester, false
+	}
+	if requester.Role != "admin" {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return requester, false
+	}
+	return requester, true
+}
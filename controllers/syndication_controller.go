@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/syndication"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const syndicationFeedSize = 50
+
+type feedFormat int
+
+const (
+	formatAtom feedFormat = iota
+	formatRSS
+)
+
+// SyndicationController serves Atom and RSS feeds of a topic's posts and of
+// a user's posts. Links point at the frontend at SiteURL.
+type SyndicationController struct {
+	DB      *gorm.DB
+	SiteURL string
+}
+
+func NewSyndicationController(db *gorm.DB, siteURL string) *SyndicationController {
+	return &SyndicationController{DB: db, SiteURL: strings.TrimRight(siteURL, "/")}
+}
+
+func (c *SyndicationController) RegisterRoutes(r chi.Router) {
+	r.Get("/topics/{topicId}/feed.atom", c.GetTopicFeed(formatAtom))
+	r.Get("/topics/{topicId}/feed.rss", c.GetTopicFeed(formatRSS))
+	r.Get("/users/{profileId}/feed.atom", c.GetUserFeed(formatAtom))
+	r.Get("/users/{profileId}/feed.rss", c.GetUserFeed(formatRSS))
+}
+
+// GetTopicFeed lists the topic's posts as GetPostsByTopic does, including
+// its q, tag, solved and sort parameters, capped at 50 posts.
+func (c *SyndicationController) GetTopicFeed(format feedFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicID, err := utils.ParseUintParam(r, "topicId")
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+			return
+		}
+
+		var topic models.Topic
+		if err := c.DB.First(&topic, topicID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "topic not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topic")
+			return
+		}
+
+		dbq, err := topicPostsQuery(c.DB, topic.ID, r)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var posts []models.Post
+		if err := dbq.Limit(syndicationFeedSize).Find(&posts).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch posts")
+			return
+		}
+
+		feed := c.newFeed(r, posts)
+		feed.ID = syndication.TagURI(c.authority(), topic.CreatedAt, fmt.Sprintf("topic:%d", topic.ID))
+		feed.Title = topic.Title
+		feed.Subtitle = topic.Description
+		feed.Link = fmt.Sprintf("%s/topics/%d", c.SiteURL, topic.ID)
+		if topic.UpdatedAt.After(feed.Updated) {
+			feed.Updated = topic.UpdatedAt
+		}
+
+		writeFeed(w, r, feed, format)
+	}
+}
+
+func (c *SyndicationController) GetUserFeed(format feedFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profileID, err := utils.ParseUintParam(r, "profileId")
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid userId")
+			return
+		}
+
+		var user models.User
+		if err := c.DB.Select("id", "username", "created_at").First(&user, profileID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utils.WriteError(w, http.StatusNotFound, "user not found")
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch user")
+			return
+		}
+
+		var posts []models.Post
+		if err := c.DB.
+			Where("user_id = ?", user.ID).
+			Scopes(preloadPostDetails).
+			Order("created_at DESC").
+			Limit(syndicationFeedSize).
+			Find(&posts).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch posts")
+			return
+		}
+
+		feed := c.newFeed(r, posts)
+		feed.ID = syndication.TagURI(c.authority(), user.CreatedAt, fmt.Sprintf("user:%d", user.ID))
+		feed.Title = "Posts by " + user.Username
+		feed.Link = fmt.Sprintf("%s/users/%d", c.SiteURL, user.ID)
+		if feed.Updated.IsZero() {
+			feed.Updated = user.CreatedAt
+		}
+
+		writeFeed(w, r, feed, format)
+	}
+}
+
+// newFeed maps posts to entries. Entry IDs depend only on the post, and an
+// entry's updated time is its EditedAt, falling back to CreatedAt. The
+// feed's Updated is the latest entry update.
+func (c *SyndicationController) newFeed(r *http.Request, posts []models.Post) syndication.Feed {
+	feed := syndication.Feed{SelfLink: requestURL(r)}
+	for _, p := range posts {
+		updated := p.CreatedAt
+		if p.EditedAt != nil {
+			updated = *p.EditedAt
+		}
+		if updated.After(feed.Updated) {
+			feed.Updated = updated
+		}
+
+		entry := syndication.Entry{
+			ID:        syndication.TagURI(c.authority(), p.CreatedAt, fmt.Sprintf("post:%d", p.ID)),
+			Title:     p.Title,
+			Link:      fmt.Sprintf("%s/posts/%d", c.SiteURL, p.ID),
+			Author:    p.User.Username,
+			HTML:      types.RenderedBody(p.RenderedBody, p.Body),
+			Published: p.CreatedAt,
+			Updated:   updated,
+		}
+		for _, t := range p.Tags {
+			entry.Categories = append(entry.Categories, t.Name)
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// authority is the tag: URI authority for IDs, the site's host name.
+func (c *SyndicationController) authority() string {
+	u, err := url.Parse(c.SiteURL)
+	if err != nil || u.Hostname() == "" {
+		return "localhost"
+	}
+	return u.Hostname()
+}
+
+// writeFeed renders feed and serves it with an ETag over the exact bytes,
+// so readers that send If-None-Match get a 304 when nothing changed. There
+// is no Last-Modified: feed.Updated only tracks entries still in the feed,
+// so it doesn't advance when a post is deleted or filtered out.
+func writeFeed(w http.ResponseWriter, r *http.Request, feed syndication.Feed, format feedFormat) {
+	var body []byte
+	var err error
+	switch format {
+	case formatRSS:
+		body, err = feed.RSS()
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	default:
+		body, err = feed.Atom()
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	}
+	if err != nil {
+		log.Printf("syndication: render %s: %v", feed.ID, err)
+		w.Header().Del("Content-Type")
+		utils.WriteError(w, http.StatusInternalServerError, "failed to render feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// requestURL rebuilds the absolute URL the client requested, honouring
+// X-Forwarded-Proto from a TLS-terminating proxy.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if p := r.Header.Get("X-Forwarded-Proto"); p == "https" || p == "http" {
+		scheme = p
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
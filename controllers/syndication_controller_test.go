@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+func testFeedPosts() []models.Post {
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	edited := created.Add(48 * time.Hour)
+	return []models.Post{
+		{
+			ID:           2,
+			Title:        "Edited",
+			Body:         "**new**",
+			RenderedBody: "<p><strong>new</strong></p>\n",
+			CreatedAt:    created.Add(time.Hour),
+			EditedAt:     &edited,
+			User:         models.User{Username: "alice"},
+			Tags:         []models.Tag{{Name: "meta"}},
+		},
+		{
+			// Written before bodies were rendered on save.
+			ID:        1,
+			Title:     "Old",
+			Body:      "*legacy*",
+			CreatedAt: created,
+			User:      models.User{Username: "bob"},
+		},
+	}
+}
+
+func TestNewFeedEntries(t *testing.T) {
+	c := NewSyndicationController(nil, "https://forum.example/")
+	r := httptest.NewRequest(http.MethodGet, "/topics/1/feed.atom?tag=meta", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	posts := testFeedPosts()
+
+	feed := c.newFeed(r, posts)
+
+	if feed.SelfLink != "https://example.com/topics/1/feed.atom?tag=meta" {
+		t.Errorf("SelfLink = %q", feed.SelfLink)
+	}
+	if !feed.Updated.Equal(*posts[0].EditedAt) {
+		t.Errorf("feed Updated = %v, want the newest edit %v", feed.Updated, *posts[0].EditedAt)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("%d entries, want 2", len(feed.Entries))
+	}
+
+	edited, old := feed.Entries[0], feed.Entries[1]
+	if edited.ID != "tag:forum.example,2026-03-01:post:2" || old.ID != "tag:forum.example,2026-03-01:post:1" {
+		t.Errorf("entry IDs = %q, %q", edited.ID, old.ID)
+	}
+	if edited.Link != "https://forum.example/posts/2" || edited.Author != "alice" {
+		t.Errorf("edited entry link/author = %q/%q", edited.Link, edited.Author)
+	}
+	if !edited.Updated.Equal(*posts[0].EditedAt) || !edited.Published.Equal(posts[0].CreatedAt) {
+		t.Errorf("edited entry published/updated = %v/%v, want creation and edit times", edited.Published, edited.Updated)
+	}
+	if !old.Updated.Equal(posts[1].CreatedAt) {
+		t.Errorf("unedited entry updated = %v, want its creation time", old.Updated)
+	}
+	if len(edited.Categories) != 1 || edited.Categories[0] != "meta" {
+		t.Errorf("categories = %v, want [meta]", edited.Categories)
+	}
+	if edited.HTML != posts[0].RenderedBody {
+		t.Errorf("edited entry HTML = %q, want the stored rendering", edited.HTML)
+	}
+	if old.HTML != "<p><em>legacy</em></p>\n" {
+		t.Errorf("unrendered entry HTML = %q, want the body rendered on the fly", old.HTML)
+	}
+}
+
+func TestNewFeedIDsSurviveEdits(t *testing.T) {
+	c := NewSyndicationController(nil, "https://forum.example")
+	r := httptest.NewRequest(http.MethodGet, "/users/1/feed.atom", nil)
+	posts := testFeedPosts()
+	before := c.newFeed(r, posts).Entries[0].ID
+
+	edited := posts[0].EditedAt.Add(time.Hour)
+	posts[0].EditedAt = &edited
+	posts[0].Title = "Edited again"
+	if after := c.newFeed(r, posts).Entries[0].ID; after != before {
+		t.Fatalf("entry ID changed on edit: %q -> %q", before, after)
+	}
+}
+
+func TestWriteFeed(t *testing.T) {
+	c := NewSyndicationController(nil, "https://forum.example")
+	r := httptest.NewRequest(http.MethodGet, "/topics/1/feed.atom", nil)
+	feed := c.newFeed(r, testFeedPosts())
+
+	rec := httptest.NewRecorder()
+	writeFeed(rec, r, feed, formatAtom)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if lm := rec.Header().Get("Last-Modified"); lm != "" {
+		t.Errorf("Last-Modified = %q, want none", lm)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "&lt;p&gt;&lt;em&gt;legacy&lt;/em&gt;&lt;/p&gt;") {
+		t.Errorf("Atom content is missing the rendered legacy body:\n%s", body)
+	}
+
+	rss := httptest.NewRecorder()
+	writeFeed(rss, r, feed, formatRSS)
+	if ct := rss.Header().Get("Content-Type"); ct != "application/rss+xml; charset=utf-8" {
+		t.Errorf("RSS Content-Type = %q", ct)
+	}
+	if !strings.Contains(rss.Body.String(), "<description>&lt;p&gt;&lt;em&gt;legacy") {
+		t.Errorf("RSS description is missing the rendered legacy body:\n%s", rss.Body)
+	}
+	if rss.Header().Get("ETag") == etag {
+		t.Error("Atom and RSS bodies share an ETag")
+	}
+
+	cached := httptest.NewRequest(http.MethodGet, "/topics/1/feed.atom", nil)
+	cached.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	writeFeed(rec, cached, feed, formatAtom)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("conditional request = %d with %d bytes, want 304 and no body", rec.Code, rec.Body.Len())
+	}
+
+	// Dropping an entry (a deleted post) must change the validator even
+	// though no remaining entry is newer.
+	feed.Entries = feed.Entries[:1]
+	rec = httptest.NewRecorder()
+	writeFeed(rec, cached, feed, formatAtom)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("feed without a deleted post = %d with ETag %q, want 200 and a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+}
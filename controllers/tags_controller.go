@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxTagsPerPost = 5
+
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+type TagsController struct {
+	DB *gorm.DB
+}
+
+func NewTagsController(db *gorm.DB) *TagsController {
+	return &TagsController{DB: db}
+}
+
+func (c *TagsController) RegisterRoutes(r chi.Router) {
+	r.Get("/tags", c.GetTags)
+	r.Patch("/tags/{tagId}", c.RenameTag)
+	r.Post("/tags/{tagId}/merge", c.MergeTag)
+}
+
+// GetTags backs tag autocomplete: tags starting with q, most used first.
+func (c *TagsController) GetTags(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+
+	limit := 10
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, 50)
+	}
+
+	dbq := c.DB.
+		Table("tags").
+		Select("tags.id, tags.name, COUNT(post_tags.post_id) AS count").
+		Joins("LEFT JOIN post_tags ON post_tags.tag_id = tags.id").
+		Group("tags.id").
+		Order("count DESC").
+		Order("tags.name ASC").
+		Limit(limit)
+
+	if q != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q)
+		dbq = dbq.Where("tags.name LIKE ?", escaped+"%")
+	}
+
+	out := []types.TagResponse{}
+	if err := dbq.Scan(&out).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch tags")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+func (c *TagsController) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := utils.ParseUintParam(r, "tagId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid tagId")
+		return
+	}
+
+	type renameTagRequest struct {
+		UserID uint   `json:"userId"`
+		Name   string `json:"name"`
+	}
+
+	var req renameTagRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	names, err := normalizeTags([]string{req.Name})
+	if err != nil || len(names) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid tag name")
+		return
+	}
+
+	tag, requester, ok := c.loadTagForModerator(w, tagID, req.UserID)
+	if !ok {
+		return
+	}
+
+	before := tag
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&tag).Update("name", names[0]).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "tag.rename", "tag", tag.ID, before, tag)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			utils.WriteError(w, http.StatusConflict, "tag name already exists (merge instead)")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to rename tag")
+		return
+	}
+
+	count, err := c.countPosts(tag.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count tagged posts")
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.TagResponse{ID: tag.ID, Name: tag.Name, Count: count})
+}
+
+// MergeTag moves every post from the tag in the URL onto intoTagId and then
+// deletes the merged tag.
+func (c *TagsController) MergeTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := utils.ParseUintParam(r, "tagId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid tagId")
+		return
+	}
+
+	type mergeTagRequest struct {
+		UserID    uint `json:"userId"`
+		IntoTagID uint `json:"intoTagId"`
+	}
+
+	var req mergeTagRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.IntoTagID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "intoTagId is required")
+		return
+	}
+	if req.IntoTagID == tagID {
+		utils.WriteError(w, http.StatusBadRequest, "cannot merge a tag into itself")
+		return
+	}
+
+	source, requester, ok := c.loadTagForModerator(w, tagID, req.UserID)
+	if !ok {
+		return
+	}
+
+	var target models.Tag
+	if err := c.DB.First(&target, req.IntoTagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "target tag not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch tag")
+		return
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO post_tags (post_id, tag_id, created_at)
+			SELECT post_id, ?, created_at FROM post_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, target.ID, source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", source.ID).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&source).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "tag.merge", "tag", source.ID, source, target)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to merge tags")
+		return
+	}
+
+	count, err := c.countPosts(target.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count tagged posts")
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.TagResponse{ID: target.ID, Name: target.Name, Count: count})
+}
+
+func (c *TagsController) loadTagForModerator(w http.ResponseWriter, tagID, userID uint) (models.Tag, models.User, bool) {
+	var tag models.Tag
+	if err := c.DB.First(&tag, tagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "tag not found")
+			return tag, models.User{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch tag")
+		return tag, models.User{}, false
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return tag, requester, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return tag, requester, false
+	}
+	if !isPrivileged(requester) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return tag, requester, false
+	}
+
+	return tag, requester, true
+}
+
+func (c *TagsController) countPosts(tagID uint) (int64, error) {
+	var count int64
+	err := c.DB.Model(&models.PostTag{}).Where("tag_id = ?", tagID).Count(&count).Error
+	return count, err
+}
+
+// normalizeTags lower-cases and de-duplicates tag names, keeping the order
+// they were given in.
+func normalizeTags(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(raw))
+	for _, t := range raw {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !tagNamePattern.MatchString(t) {
+			return nil, errors.New("invalid tag " + strconv.Quote(t) + " (letters, digits and dashes, max 32)")
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxTagsPerPost {
+		return nil, errors.New("too many tags (max " + strconv.Itoa(maxTagsPerPost) + ")")
+	}
+	return out, nil
+}
+
+// upsertTags returns the tags with the given names, creating missing ones.
+func upsertTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	rows := make([]models.Tag, 0, len(names))
+	for _, n := range names {
+		rows = append(rows, models.Tag{Name: n})
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var tags []models.Tag
+	if err := tx.Where("name IN ?", names).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// filterPostsByTags keeps posts carrying every tag in names.
+func filterPostsByTags(names []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, n := range names {
+			n = strings.ToLower(strings.TrimSpace(n))
+			if n == "" {
+				continue
+			}
+			db = db.Where("posts.id IN (SELECT post_tags.post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.name = ?)", n)
+		}
+		return db
+	}
+}
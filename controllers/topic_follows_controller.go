@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TopicFollowsController struct {
+	DB *gorm.DB
+}
+
+func NewTopicFollowsController(db *gorm.DB) *TopicFollowsController {
+	return &TopicFollowsController{DB: db}
+}
+
+func (c *TopicFollowsController) RegisterRoutes(r chi.Router) {
+	r.Get("/users/me/topics", c.GetFollowedTopics)
+	r.Post("/topics/{topicId}/follow", c.FollowTopic)
+	r.Delete("/topics/{topicId}/follow", c.UnfollowTopic)
+}
+
+func (c *TopicFollowsController) GetFollowedTopics(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var topics []models.Topic
+	if err := c.DB.
+		Joins("JOIN topic_follows ON topic_follows.topic_id = topics.id AND topic_follows.user_id = ?", userID).
+		Preload("CreatedByUser", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("topics.title ASC").
+		Find(&topics).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topics")
+		return
+	}
+
+	out := make([]types.TopicResponse, 0, len(topics))
+	for _, t := range topics {
+		out = append(out, types.ToTopicResponse(t))
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+// FollowTopic is idempotent: following an already followed topic succeeds.
+func (c *TopicFollowsController) FollowTopic(w http.ResponseWriter, r *http.Request) {
+	follow, ok := c.decodeFollow(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to follow topic")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *TopicFollowsController) UnfollowTopic(w http.ResponseWriter, r *http.Request) {
+	follow, ok := c.decodeFollow(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.
+		Where("user_id = ? AND topic_id = ?", follow.UserID, follow.TopicID).
+		Delete(&models.TopicFollow{}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to unfollow topic")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *TopicFollowsController) decodeFollow(w http.ResponseWriter, r *http.Request) (models.TopicFollow, bool) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return models.TopicFollow{}, false
+	}
+
+	type followRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req followRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return models.TopicFollow{}, false
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return models.TopicFollow{}, false
+	}
+
+	var user models.User
+	if err := c.DB.Select("id").First(&user, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return models.TopicFollow{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return models.TopicFollow{}, false
+	}
+
+	var topic models.Topic
+	if err := c.DB.Select("id").First(&topic, topicID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "topic not found")
+			return models.TopicFollow{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topic")
+		return models.TopicFollow{}, false
+	}
+
+	return models.TopicFollow{UserID: user.ID, TopicID: topic.ID}, true
+}
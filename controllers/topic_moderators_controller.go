@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type TopicModeratorsController struct {
+	DB *gorm.DB
+}
+
+func NewTopicModeratorsController(db *gorm.DB) *TopicModeratorsController {
+	return &TopicModeratorsController{DB: db}
+}
+
+func (c *TopicModeratorsController) RegisterRoutes(r chi.Router) {
+	r.Get("/topics/{topicId}/moderators", c.GetModerators)
+	r.Post("/topics/{topicId}/moderators", c.AddModerator)
+	r.Delete("/topics/{topicId}/moderators/{moderatorId}", c.RemoveModerator)
+
+	r.Post("/topics/{topicId}/transfer", c.TransferOwnership)
+}
+
+func (c *TopicModeratorsController) GetModerators(w http.ResponseWriter, r *http.Request) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return
+	}
+
+	var topic models.Topic
+	if err := c.DB.Select("id").First(&topic, topicID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "topic not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topic")
+		return
+	}
+
+	var mods []models.TopicModerator
+	if err := c.DB.
+		Where("topic_id = ?", topicID).
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at ASC").
+		Find(&mods).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch moderators")
+		return
+	}
+
+	out := make([]types.TopicModeratorResponse, 0, len(mods))
+	for _, m := range mods {
+		out = append(out, types.ToTopicModeratorResponse(m))
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+func (c *TopicModeratorsController) AddModerator(w http.ResponseWriter, r *http.Request) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return
+	}
+
+	type addModeratorRequest struct {
+		UserID      uint `json:"userId"`
+		ModeratorID uint `json:"moderatorId"`
+	}
+
+	var req addModeratorRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.ModeratorID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "moderatorId is required")
+		return
+	}
+
+	topic, requester, ok := c.loadTopicForOwner(w, topicID, req.UserID)
+	if !ok {
+		return
+	}
+
+	var moderator models.User
+	if err := c.DB.Select("id", "username").First(&moderator, req.ModeratorID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "moderator user not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	mod := models.TopicModerator{
+		TopicID:          topic.ID,
+		UserID:           moderator.ID,
+		AssignedByUserID: requester.ID,
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&mod).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "topic.moderator_add", "topic", topic.ID, nil, mod)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			utils.WriteError(w, http.StatusConflict, "user already moderates this topic")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to add moderator")
+		return
+	}
+
+	mod.User = moderator
+	utils.WriteJSON(w, http.StatusCreated, types.ToTopicModeratorResponse(mod))
+}
+
+func (c *TopicModeratorsController) RemoveModerator(w http.ResponseWriter, r *http.Request) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return
+	}
+	moderatorID, err := utils.ParseUintParam(r, "moderatorId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid moderatorId")
+		return
+	}
+
+	type removeModeratorRequest struct {
+		UserID uint `json:"userId"`
+	}
+
+	var req removeModeratorRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	var mod models.TopicModerator
+	if err := c.DB.Where("topic_id = ? AND user_id = ?", topicID, moderatorID).First(&mod).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "moderator not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch moderator")
+		return
+	}
+
+	// Moderators may step down themselves; anyone else needs owner rights.
+	actorID := req.UserID
+	if req.UserID != moderatorID {
+		_, requester, ok := c.loadTopicForOwner(w, topicID, req.UserID)
+		if !ok {
+			return
+		}
+		actorID = requester.ID
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&mod).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, actorID, "topic.moderator_remove", "topic", topicID, mod, nil)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to remove moderator")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *TopicModeratorsController) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	topicID, err := utils.ParseUintParam(r, "topicId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid topicId")
+		return
+	}
+
+	type transferRequest struct {
+		UserID     uint `json:"userId"`
+		NewOwnerID uint `json:"newOwnerId"`
+	}
+
+	var req transferRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if req.NewOwnerID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "newOwnerId is required")
+		return
+	}
+
+	topic, requester, ok := c.loadTopicForOwner(w, topicID, req.UserID)
+	if !ok {
+		return
+	}
+
+	if topic.CreatedByUserID != nil && *topic.CreatedByUserID == req.NewOwnerID {
+		utils.WriteError(w, http.StatusBadRequest, "user already owns this topic")
+		return
+	}
+
+	var newOwner models.User
+	if err := c.DB.Select("id", "username").First(&newOwner, req.NewOwnerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "new owner not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return
+	}
+
+	before := topic
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&topic).Update("created_by_user_id", newOwner.ID).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "topic.transfer", "topic", topic.ID, before, topic)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to transfer topic")
+		return
+	}
+
+	var updated models.Topic
+	if err := c.DB.
+		Preload("CreatedByUser", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		First(&updated, topicID).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch updated topic")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToTopicResponse(updated))
+}
+
+// loadTopicForOwner fetches the topic and requester, and checks that the
+// requester owns the topic or is a global moderator or admin.
+func (c *TopicModeratorsController) loadTopicForOwner(w http.ResponseWriter, topicID, userID uint) (models.Topic, models.User, bool) {
+	var topic models.Topic
+	if err := c.DB.First(&topic, topicID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "topic not found")
+			return topic, models.User{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch topic")
+		return topic, models.User{}, false
+	}
+
+	var requester models.User
+	if err := c.DB.Select("id", "role").First(&requester, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return topic, requester, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return topic, requester, false
+	}
+
+	isOwner := topic.CreatedByUserID != nil && *topic.CreatedByUserID == requester.ID
+	if !(isPrivileged(requester) || isOwner) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden")
+		return topic, requester, false
+	}
+
+	return topic, requester, true
+}
@@ -65,6 +65,7 @@ func (c *TopicsController) CreateTopic(w http.ResponseWriter, r *http.Request) {
 		Title       string `json:"title"`
 		Description string `json:"description"`
 		UserID      *uint  `json:"userId,omitempty"`
+		QAMode      bool   `json:"qaMode"`
 	}
 
 	var req createTopicRequest
@@ -99,12 +100,16 @@ func (c *TopicsController) CreateTopic(w http.ResponseWriter, r *http.Request) {
 			utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
 			return
 		}
+		if !checkBan(w, c.DB, author.ID, nil) {
+			return
+		}
 	}
 
 	topic := models.Topic{
 		Title:           req.Title,
 		Description:     req.Description,
 		CreatedByUserID: req.UserID,
+		QAMode:          req.QAMode,
 	}
 
 	if err := c.DB.Create(&topic).Error; err != nil {
@@ -121,7 +126,10 @@ func (c *TopicsController) CreateTopic(w http.ResponseWriter, r *http.Request) {
 		topic.CreatedByUser = &author
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, types.ToTopicResponse(topic))
+	resp := types.ToTopicResponse(topic)
+	queueWebhooks(c.DB, "topic.created", resp)
+
+	utils.WriteJSON(w, http.StatusCreated, resp)
 }
 
 func (c *TopicsController) UpdateTopic(w http.ResponseWriter, r *http.Request) {
@@ -135,6 +143,7 @@ func (c *TopicsController) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		UserID      uint    `json:"userId"`
 		Title       *string `json:"title,omitempty"`
 		Description *string `json:"description,omitempty"`
+		QAMode      *bool   `json:"qaMode,omitempty"`
 	}
 
 	var req updateTopicRequest
@@ -146,7 +155,7 @@ func (c *TopicsController) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, "userId is required")
 		return
 	}
-	if req.Title == nil && req.Description == nil {
+	if req.Title == nil && req.Description == nil && req.QAMode == nil {
 		utils.WriteError(w, http.StatusBadRequest, "nothing to update")
 		return
 	}
@@ -201,7 +210,21 @@ func (c *TopicsController) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		updates["description"] = d
 	}
 
-	if err := c.DB.Model(&topic).Updates(updates).Error; err != nil {
+	if req.QAMode != nil {
+		updates["qa_mode"] = *req.QAMode
+	}
+
+	before := topic
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&topic).Updates(updates).Error; err != nil {
+			return err
+		}
+		if isOwner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "topic.update", "topic", topic.ID, before, topic)
+	})
+	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			utils.WriteError(w, http.StatusConflict, "topic title already exists")
@@ -219,7 +242,10 @@ func (c *TopicsController) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.ToTopicResponse(updated))
+	resp := types.ToTopicResponse(updated)
+	queueWebhooks(c.DB, "topic.updated", resp)
+
+	utils.WriteJSON(w, http.StatusOK, resp)
 }
 
 func (c *TopicsController) DeleteTopic(w http.ResponseWriter, r *http.Request) {
@@ -269,10 +295,24 @@ func (c *TopicsController) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.DB.Delete(&topic).Error; err != nil {
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := queueTopicAttachmentCleanup(tx, topic.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&topic).Error; err != nil {
+			return err
+		}
+		if isOwner {
+			return nil
+		}
+		return writeAudit(tx, r, requester.ID, "topic.delete", "topic", topic.ID, topic, nil)
+	})
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "failed to delete topic")
 		return
 	}
 
+	queueWebhooks(c.DB, "topic.deleted", map[string]any{"id": topic.ID})
+
 	w.WriteHeader(http.StatusNoContent)
 }
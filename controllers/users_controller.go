@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsersController struct {
+	DB *gorm.DB
+}
+
+func NewUsersController(db *gorm.DB) *UsersController {
+	return &UsersController{DB: db}
+}
+
+func (c *UsersController) RegisterRoutes(r chi.Router) {
+	r.Get("/users/{profileId}", c.GetProfile)
+	r.Get("/users/{profileId}/followers", c.GetFollowers)
+	r.Get("/users/{profileId}/following", c.GetFollowing)
+	r.Post("/users/{profileId}/follow", c.Follow)
+	r.Delete("/users/{profileId}/follow", c.Unfollow)
+}
+
+func (c *UsersController) GetProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := c.loadProfileUser(w, r)
+	if !ok {
+		return
+	}
+
+	out := types.UserProfileResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	}
+
+	counts := []struct {
+		dst   *int64
+		model any
+		where string
+	}{
+		{&out.FollowerCount, &models.UserFollow{}, "followee_id = ?"},
+		{&out.FollowingCount, &models.UserFollow{}, "follower_id = ?"},
+		{&out.PostCount, &models.Post{}, "user_id = ?"},
+	}
+	for _, q := range counts {
+		if err := c.DB.Model(q.model).Where(q.where, user.ID).Count(q.dst).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch profile")
+			return
+		}
+	}
+
+	if viewer := viewerID(r); viewer != 0 {
+		var n int64
+		if err := c.DB.Model(&models.UserFollow{}).
+			Where("follower_id = ? AND followee_id = ?", viewer, user.ID).
+			Count(&n).Error; err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "failed to fetch profile")
+			return
+		}
+		following := n > 0
+		out.Following = &following
+	}
+
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+func (c *UsersController) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	c.listFollows(w, r, "followee_id", "Follower")
+}
+
+func (c *UsersController) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	c.listFollows(w, r, "follower_id", "Followee")
+}
+
+// listFollows pages through the follow edges where column equals the
+// profile user, returning the user on the other end (assoc).
+func (c *UsersController) listFollows(w http.ResponseWriter, r *http.Request, column, assoc string) {
+	user, ok := c.loadProfileUser(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+	dbq := c.DB.Model(&models.UserFollow{}).Where(column+" = ?", user.ID)
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count follows")
+		return
+	}
+
+	var follows []models.UserFollow
+	if err := dbq.
+		Preload(assoc, func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&follows).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch follows")
+		return
+	}
+
+	out := make([]types.FollowResponse, 0, len(follows))
+	for _, f := range follows {
+		other := f.Followee
+		if assoc == "Follower" {
+			other = f.Follower
+		}
+		out = append(out, types.FollowResponse{User: types.ToUserPublic(other), FollowedAt: f.CreatedAt})
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.FollowResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// Follow is idempotent; the followee is only notified the first time.
+func (c *UsersController) Follow(w http.ResponseWriter, r *http.Request) {
+	follower, followee, ok := c.decodeFollow(w, r)
+	if !ok {
+		return
+	}
+	if follower.ID == followee.ID {
+		utils.WriteError(w, http.StatusBadRequest, "cannot follow yourself")
+		return
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.UserFollow{FollowerID: follower.ID, FolloweeID: followee.ID})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		notifications := []models.Notification{{UserID: followee.ID, Type: "follow", ActorID: follower.ID}}
+		return createNotifications(tx, notifications)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to follow user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *UsersController) Unfollow(w http.ResponseWriter, r *http.Request) {
+	follower, followee, ok := c.decodeFollow(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.DB.
+		Where("follower_id = ? AND followee_id = ?", follower.ID, followee.ID).
+		Delete(&models.UserFollow{}).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to unfollow user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *UsersController) loadProfileUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	profileID, err := utils.ParseUintParam(r, "profileId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid userId")
+		return models.User{}, false
+	}
+
+	var user models.User
+	if err := c.DB.Select("id", "username", "created_at").First(&user, profileID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "user not found")
+			return models.User{}, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch user")
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// decodeFollow loads the followee from the path and the follower from the
+// {userId} body.
+func (c *UsersController) decodeFollow(w http.ResponseWriter, r *http.Request) (follower, followee models.User, ok bool) {
+	followee, ok = c.loadProfileUser(w, r)
+	if !ok {
+		return
+	}
+
+	type followRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req followRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return follower, followee, false
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return follower, followee, false
+	}
+
+	if err := c.DB.Select("id", "username").First(&follower, req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, "user not found")
+			return follower, followee, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "db error checking user")
+		return follower, followee, false
+	}
+	return follower, followee, true
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// viewerID returns the optional ?userId= identifying who is reading, or 0
+// for anonymous requests.
+func viewerID(r *http.Request) uint {
+	id, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// applyViewerState fills the per-viewer fields of posts: poll results, the
+// bookmarked flag, whether the author is muted or blocked and unread
+// comments. Anonymous viewers get poll results only.
+func applyViewerState(db *gorm.DB, viewerID uint, posts []types.PostResponse) error {
+	if err := applyPollState(db, viewerID, posts); err != nil {
+		return err
+	}
+	if viewerID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+
+	var bookmarked []uint
+	if err := db.Model(&models.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", viewerID, ids).
+		Pluck("post_id", &bookmarked).Error; err != nil {
+		return err
+	}
+	saved := make(map[uint]bool, len(bookmarked))
+	for _, id := range bookmarked {
+		saved[id] = true
+	}
+
+	hidden, err := hiddenAuthors(db, viewerID)
+	if err != nil {
+		return err
+	}
+
+	unread, err := unreadComments(db, viewerID, ids, hidden)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		b := saved[posts[i].ID]
+		posts[i].Bookmarked = &b
+		posts[i].Muted = hidden[posts[i].UserID]
+
+		u := unread[posts[i].ID]
+		posts[i].UnreadCount = &u.Count
+		if u.Count > 0 {
+			first := u.FirstID
+			posts[i].FirstUnreadCommentID = &first
+		}
+	}
+	return nil
+}
+
+type unreadRow struct {
+	PostID  uint
+	Count   int64
+	FirstID uint
+}
+
+// unreadComments counts, per post, the comments after viewerID's read
+// marker. The viewer's own comments and those by authors they muted or
+// blocked never count as unread.
+func unreadComments(db *gorm.DB, viewerID uint, postIDs []uint, hidden map[uint]bool) (map[uint]unreadRow, error) {
+	dbq := db.Table("comments").
+		Select("comments.post_id, COUNT(*) AS count, MIN(comments.id) AS first_id").
+		Joins("LEFT JOIN post_reads ON post_reads.post_id = comments.post_id AND post_reads.user_id = ?", viewerID).
+		Where("comments.post_id IN ? AND comments.user_id <> ?", postIDs, viewerID).
+		Where("(post_reads.last_read_comment_id IS NULL OR comments.id > post_reads.last_read_comment_id)").
+		Group("comments.post_id")
+	if len(hidden) > 0 {
+		authors := make([]uint, 0, len(hidden))
+		for id := range hidden {
+			authors = append(authors, id)
+		}
+		dbq = dbq.Where("comments.user_id NOT IN ?", authors)
+	}
+
+	var rows []unreadRow
+	if err := dbq.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]unreadRow, len(rows))
+	for _, row := range rows {
+		out[row.PostID] = row
+	}
+	return out, nil
+}
+
+// applyCommentViewerState flags comments whose author the viewer muted or
+// blocked.
+func applyCommentViewerState(db *gorm.DB, viewerID uint, comments []types.CommentResponse) error {
+	if viewerID == 0 || len(comments) == 0 {
+		return nil
+	}
+
+	hidden, err := hiddenAuthors(db, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Muted = hidden[comments[i].UserID]
+	}
+	return nil
+}
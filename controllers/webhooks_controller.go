@@ -0,0 +1,606 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+	"CVWO-Backend/types"
+	"CVWO-Backend/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookEvents are the events subscriptions can ask for. Private activity
+// such as direct messages and notifications is deliberately left out.
+var webhookEvents = []string{
+	"topic.created", "topic.updated", "topic.deleted",
+	"post.created", "post.updated", "post.deleted",
+	"comment.created", "comment.updated", "comment.deleted",
+}
+
+const (
+	deliverWebhookJob   = "webhooks.deliver"
+	webhookMaxAttempts  = 8
+	webhookTimeout      = 10 * time.Second
+	webhookResponseKeep = 2048
+)
+
+// WebhooksController manages outbound webhook subscriptions (admin only)
+// and delivers their events.
+type WebhooksController struct {
+	DB     *gorm.DB
+	Client *http.Client
+}
+
+func NewWebhooksController(db *gorm.DB) *WebhooksController {
+	return &WebhooksController{DB: db, Client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (c *WebhooksController) RegisterRoutes(r chi.Router) {
+	r.Get("/admin/webhooks", c.GetWebhooks)
+	r.Post("/admin/webhooks", c.CreateWebhook)
+	r.Patch("/admin/webhooks/{webhookId}", c.UpdateWebhook)
+	r.Delete("/admin/webhooks/{webhookId}", c.DeleteWebhook)
+	r.Get("/admin/webhooks/{webhookId}/deliveries", c.GetDeliveries)
+	r.Post("/admin/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", c.Redeliver)
+}
+
+func (c *WebhooksController) RegisterJobs(q *jobs.Queue) {
+	q.Register(deliverWebhookJob, c.deliver)
+}
+
+func (c *WebhooksController) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if _, ok := requireAdmin(w, c.DB, userID); !ok {
+		return
+	}
+
+	var subs []models.WebhookSubscription
+	if err := c.DB.Order("id").Find(&subs).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch webhooks")
+		return
+	}
+
+	out := make([]types.WebhookResponse, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, types.ToWebhookResponse(s))
+	}
+	utils.WriteJSON(w, http.StatusOK, out)
+}
+
+// CreateWebhook adds a subscription. When no secret is given one is
+// generated; either way it is only returned here.
+func (c *WebhooksController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	type createWebhookRequest struct {
+		UserID      uint     `json:"userId"`
+		URL         string   `json:"url"`
+		Secret      string   `json:"secret"`
+		Events      []string `json:"events"`
+		Description string   `json:"description"`
+		Active      *bool    `json:"active,omitempty"`
+	}
+	var req createWebhookRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	requester, ok := requireAdmin(w, c.DB, req.UserID)
+	if !ok {
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		Description:     strings.TrimSpace(req.Description),
+		Active:          req.Active == nil || *req.Active,
+		CreatedByUserID: requester.ID,
+	}
+	var err error
+	if sub.URL, err = validateWebhookURL(req.URL); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sub.Events, err = validateWebhookEvents(req.Events); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(sub.Description) > 200 {
+		utils.WriteError(w, http.StatusBadRequest, "description too long (max 200)")
+		return
+	}
+	if sub.Secret, err = webhookSecret(req.Secret); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sub).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "webhook.create", "webhook", sub.ID, nil, sub)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+
+	resp := types.ToWebhookResponse(sub)
+	resp.Secret = sub.Secret
+	utils.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (c *WebhooksController) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	type updateWebhookRequest struct {
+		UserID      uint      `json:"userId"`
+		URL         *string   `json:"url,omitempty"`
+		Secret      *string   `json:"secret,omitempty"`
+		Events      *[]string `json:"events,omitempty"`
+		Description *string   `json:"description,omitempty"`
+		Active      *bool     `json:"active,omitempty"`
+	}
+	var req updateWebhookRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	requester, ok := requireAdmin(w, c.DB, req.UserID)
+	if !ok {
+		return
+	}
+
+	sub, ok := c.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	before := sub
+	if req.URL != nil {
+		u, err := validateWebhookURL(*req.URL)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		sub.URL = u
+	}
+	if req.Events != nil {
+		events, err := validateWebhookEvents(*req.Events)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		sub.Events = events
+	}
+	if req.Description != nil {
+		d := strings.TrimSpace(*req.Description)
+		if len(d) > 200 {
+			utils.WriteError(w, http.StatusBadRequest, "description too long (max 200)")
+			return
+		}
+		sub.Description = d
+	}
+	if req.Secret != nil {
+		secret, err := webhookSecret(*req.Secret)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		sub.Secret = secret
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&sub).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "webhook.update", "webhook", sub.ID, before, sub)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to update webhook")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ToWebhookResponse(sub))
+}
+
+func (c *WebhooksController) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	type deleteWebhookRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req deleteWebhookRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	requester, ok := requireAdmin(w, c.DB, req.UserID)
+	if !ok {
+		return
+	}
+
+	sub, ok := c.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&sub).Error; err != nil {
+			return err
+		}
+		return writeAudit(tx, r, requester.ID, "webhook.delete", "webhook", sub.ID, sub, nil)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *WebhooksController) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUintQuery(r, "userId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if _, ok := requireAdmin(w, c.DB, userID); !ok {
+		return
+	}
+
+	sub, ok := c.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := utils.ParsePagination(r)
+
+	dbq := c.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", sub.ID)
+	if v := r.URL.Query().Get("status"); v != "" {
+		dbq = dbq.Where("status = ?", v)
+	}
+	if v := r.URL.Query().Get("event"); v != "" {
+		dbq = dbq.Where("event = ?", v)
+	}
+
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to count deliveries")
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := dbq.
+		Order("created_at DESC, id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&deliveries).Error; err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch deliveries")
+		return
+	}
+
+	out := make([]types.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		out = append(out, types.ToWebhookDeliveryResponse(d))
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PageResponse[types.WebhookDeliveryResponse]{
+		Items:    out,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// Redeliver sends a past delivery's payload again as a new delivery.
+func (c *WebhooksController) Redeliver(w http.ResponseWriter, r *http.Request) {
+	type redeliverRequest struct {
+		UserID uint `json:"userId"`
+	}
+	var req redeliverRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if req.UserID == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+	if _, ok := requireAdmin(w, c.DB, req.UserID); !ok {
+		return
+	}
+
+	sub, ok := c.findWebhook(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := utils.ParseUintParam(r, "deliveryId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid deliveryId")
+		return
+	}
+
+	var original models.WebhookDelivery
+	if err := c.DB.Where("subscription_id = ?", sub.ID).First(&original, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "delivery not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch delivery")
+		return
+	}
+
+	delivery := models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         "pending",
+		RedeliveryOfID: &original.ID,
+	}
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		return createDelivery(tx, &delivery)
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "failed to queue redelivery")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, types.ToWebhookDeliveryResponse(delivery))
+}
+
+func (c *WebhooksController) findWebhook(w http.ResponseWriter, r *http.Request) (models.WebhookSubscription, bool) {
+	var sub models.WebhookSubscription
+	webhookID, err := utils.ParseUintParam(r, "webhookId")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid webhookId")
+		return sub, false
+	}
+	if err := c.DB.First(&sub, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "webhook not found")
+			return sub, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "failed to fetch webhook")
+		return sub, false
+	}
+	return sub, true
+}
+
+func validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("url must be an absolute http(s) URL")
+	}
+	if len(raw) > 2048 {
+		return "", errors.New("url too long (max 2048)")
+	}
+	return raw, nil
+}
+
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, errors.New("at least one event is required")
+	}
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(webhookEvents, e) {
+			return nil, errors.New("unknown event " + strconv.Quote(e))
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// webhookSecret returns secret, or a random one when it is empty.
+func webhookSecret(secret string) (string, error) {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	}
+	if len(secret) < 16 || len(secret) > 128 {
+		return "", errors.New("secret must be 16 to 128 characters")
+	}
+	return secret, nil
+}
+
+// webhookEnvelope is the JSON body of every delivery.
+type webhookEnvelope struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+type deliverWebhookPayload struct {
+	DeliveryID uint `json:"deliveryId"`
+}
+
+// queueWebhooks records a delivery of event for every active subscription
+// that wants it and enqueues the sends. Call it after the change commits,
+// next to publishEvent, with the same data.
+func queueWebhooks(db *gorm.DB, event string, data any) {
+	var subs []models.WebhookSubscription
+	if err := db.Where("active = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("webhooks: queue %s: %v", event, err)
+		return
+	}
+	subs = slices.DeleteFunc(subs, func(s models.WebhookSubscription) bool {
+		return !slices.Contains(s.Events, event)
+	})
+	if len(subs) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(webhookEnvelope{Event: event, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("webhooks: encode %s: %v", event, err)
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range subs {
+			d := models.WebhookDelivery{
+				SubscriptionID: s.ID,
+				Event:          event,
+				Payload:        string(raw),
+				Status:         "pending",
+			}
+			if err := createDelivery(tx, &d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("webhooks: queue %s: %v", event, err)
+	}
+}
+
+// createDelivery stores d and the job that sends it.
+func createDelivery(tx *gorm.DB, d *models.WebhookDelivery) error {
+	if err := tx.Omit(clause.Associations).Create(d).Error; err != nil {
+		return err
+	}
+	payload := deliverWebhookPayload{DeliveryID: d.ID}
+	return jobs.Enqueue(tx, deliverWebhookJob, payload, jobs.MaxAttempts(webhookMaxAttempts))
+}
+
+// deliver is the deliverWebhookJob handler. Failed sends return an error so
+// the queue retries them with backoff, until the delivery has used
+// webhookMaxAttempts and is marked failed.
+func (c *WebhooksController) deliver(ctx context.Context, db *gorm.DB, payload json.RawMessage) error {
+	var p deliverWebhookPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	var d models.WebhookDelivery
+	if err := db.Preload("Subscription").First(&d, p.DeliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if d.Status == "succeeded" || d.Status == "failed" {
+		return nil
+	}
+	if !d.Subscription.Active {
+		return db.Model(&d).Omit(clause.Associations).Update("status", "skipped").Error
+	}
+
+	status, body, sendErr := c.send(ctx, d)
+
+	now := time.Now()
+	updates := map[string]any{
+		"attempts":        d.Attempts + 1,
+		"last_attempt_at": now,
+		"response_status": status,
+		"response_body":   body,
+		"error":           "",
+	}
+	switch {
+	case sendErr == nil:
+		updates["status"] = "succeeded"
+		updates["delivered_at"] = now
+	case d.Attempts+1 >= webhookMaxAttempts:
+		updates["status"] = "failed"
+		updates["error"] = sendErr.Error()
+	default:
+		updates["status"] = "retrying"
+		updates["error"] = sendErr.Error()
+	}
+	if err := db.Model(&d).Omit(clause.Associations).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if updates["status"] == "retrying" {
+		return sendErr
+	}
+	return nil
+}
+
+// send POSTs the delivery's payload and returns the response status and the
+// start of its body. Any non-2xx status is an error.
+func (c *WebhooksController) send(ctx context.Context, d models.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	body := []byte(d.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CVWO-Forum-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", signWebhook(d.Subscription.Secret, timestamp, body))
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Postgres text columns reject NUL and invalid UTF-8, which receivers
+	// are free to send back.
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseKeep))
+	kept := strings.ToValidUTF8(strings.ReplaceAll(string(raw), "\x00", ""), "\uFFFD")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, kept, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, kept, nil
+}
+
+// signWebhook is the X-Webhook-Signature value: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed by the subscription secret. Covering the
+// timestamp lets receivers reject replays.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
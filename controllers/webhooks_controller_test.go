@@ -0,0 +1,430 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"CVWO-Backend/jobs"
+	"CVWO-Backend/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testWebhookSecret = "0123456789abcdef-secret"
+
+// webhookReceiver is an httptest.Server that records each request and
+// answers with the next status in statuses, repeating the last one.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+	rcv := &webhookReceiver{statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		n := len(rcv.requests)
+		rcv.requests = append(rcv.requests, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := rcv.statuses[min(n, len(rcv.statuses)-1)]
+		rcv.mu.Unlock()
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "reply %d", n+1)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *webhookReceiver) received() []receivedWebhook {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]receivedWebhook(nil), rcv.requests...)
+}
+
+// checkWebhookSignature recomputes the HMAC independently of signWebhook.
+func checkWebhookSignature(t *testing.T, req receivedWebhook) {
+	t.Helper()
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("X-Webhook-Timestamp = %q, want unix seconds", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "." + string(req.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	got := req.header.Get("X-Webhook-Signature")
+	if got != want {
+		t.Fatalf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if got != signWebhook(testWebhookSecret, timestamp, req.body) {
+		t.Fatal("X-Webhook-Signature does not match signWebhook")
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	got := signWebhook("secret", "1700000000", []byte(`{"event":"post.created"}`))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1700000000.{"event":"post.created"}`))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Fatalf("signWebhook = %q, want %q", got, want)
+	}
+	if signWebhook("secret", "1700000001", []byte(`{"event":"post.created"}`)) == got {
+		t.Fatal("signature does not cover the timestamp")
+	}
+	if signWebhook("other-secret", "1700000000", []byte(`{"event":"post.created"}`)) == got {
+		t.Fatal("signature does not depend on the secret")
+	}
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	rcv := newWebhookReceiver(t, http.StatusOK)
+	c := NewWebhooksController(nil)
+	d := models.WebhookDelivery{
+		ID:           42,
+		Event:        "post.created",
+		Payload:      `{"event":"post.created","data":{"id":1}}`,
+		Subscription: models.WebhookSubscription{URL: rcv.URL, Secret: testWebhookSecret},
+	}
+
+	status, body, err := c.send(context.Background(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK || body != "reply 1" {
+		t.Fatalf("send = (%d, %q), want (200, %q)", status, body, "reply 1")
+	}
+
+	reqs := rcv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if string(req.body) != d.Payload {
+		t.Fatalf("body = %s, want %s", req.body, d.Payload)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-Webhook-Event":    "post.created",
+		"X-Webhook-Delivery": "42",
+	} {
+		if got := req.header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	checkWebhookSignature(t, req)
+}
+
+func TestSendRejectsNon2xx(t *testing.T) {
+	rcv := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	c := NewWebhooksController(nil)
+	d := models.WebhookDelivery{
+		Payload:      `{}`,
+		Subscription: models.WebhookSubscription{URL: rcv.URL, Secret: testWebhookSecret},
+	}
+
+	status, body, err := c.send(context.Background(), d)
+	if err == nil {
+		t.Fatal("send succeeded on a 503")
+	}
+	if status != http.StatusServiceUnavailable || body != "reply 1" {
+		t.Fatalf("send = (%d, %q), want (503, %q)", status, body, "reply 1")
+	}
+}
+
+func TestSendTrimsResponseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a\x00b\xff"))
+		w.Write(bytes.Repeat([]byte("x"), 2*webhookResponseKeep))
+	}))
+	defer srv.Close()
+	c := NewWebhooksController(nil)
+	d := models.WebhookDelivery{
+		Payload:      `{}`,
+		Subscription: models.WebhookSubscription{URL: srv.URL, Secret: testWebhookSecret},
+	}
+
+	_, body, err := c.send(context.Background(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body, "ab�xxx") {
+		t.Fatalf("body starts %q, want NUL dropped and invalid UTF-8 replaced", body[:min(len(body), 8)])
+	}
+	if n := len(body); n > webhookResponseKeep+len("�") {
+		t.Fatalf("kept %d bytes of the response, want at most about %d", n, webhookResponseKeep)
+	}
+}
+
+// webhookTestEnv is a webhooks controller and job queue on the database
+// named by TEST_DATABASE_URL.
+type webhookTestEnv struct {
+	db    *gorm.DB
+	queue *jobs.Queue
+	c     *WebhooksController
+	admin models.User
+}
+
+func newWebhookTestEnv(t *testing.T) *webhookTestEnv {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"webhook_deliveries", "webhook_subscriptions", "jobs"} {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	admin := models.User{
+		Username:     fmt.Sprintf("whadmin%d", time.Now().UnixNano()),
+		PasswordHash: "x",
+		Role:         "admin",
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Delete(&admin) })
+
+	q := jobs.NewQueue(db)
+	jobs.SetDefault(q)
+	t.Cleanup(func() { jobs.SetDefault(nil) })
+
+	c := NewWebhooksController(db)
+	c.RegisterJobs(q)
+	return &webhookTestEnv{db: db, queue: q, c: c, admin: admin}
+}
+
+func (env *webhookTestEnv) subscribe(t *testing.T, url string) models.WebhookSubscription {
+	t.Helper()
+	sub := models.WebhookSubscription{
+		URL:             url,
+		Secret:          testWebhookSecret,
+		Events:          []string{"post.created"},
+		Active:          true,
+		CreatedByUserID: env.admin.ID,
+	}
+	if err := env.db.Create(&sub).Error; err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+// work runs the next due job, first making every queued job due so tests
+// don't wait out the backoff.
+func (env *webhookTestEnv) work(t *testing.T) {
+	t.Helper()
+	err := env.db.Model(&models.Job{}).Where("state = ?", jobs.StateQueued).
+		Update("run_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	worked, err := env.queue.Work(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !worked {
+		t.Fatal("no job was due")
+	}
+}
+
+func (env *webhookTestEnv) delivery(t *testing.T, id uint) models.WebhookDelivery {
+	t.Helper()
+	var d models.WebhookDelivery
+	if err := env.db.First(&d, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func (env *webhookTestEnv) onlyDelivery(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+	var ds []models.WebhookDelivery
+	if err := env.db.Find(&ds).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 1 {
+		t.Fatalf("%d deliveries, want 1", len(ds))
+	}
+	return ds[0]
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	env := newWebhookTestEnv(t)
+	env.queue.Backoff = func(attempts int) time.Duration { return time.Duration(attempts) * time.Hour }
+	rcv := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	env.subscribe(t, rcv.URL)
+
+	queueWebhooks(env.db, "post.created", map[string]uint{"id": 1})
+	queueWebhooks(env.db, "post.updated", map[string]uint{"id": 1})
+	d := env.onlyDelivery(t)
+
+	for attempt, status := range []int{http.StatusInternalServerError, http.StatusBadGateway} {
+		before := time.Now()
+		env.work(t)
+
+		d = env.delivery(t, d.ID)
+		if d.Status != "retrying" || d.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: delivery status %q after %d attempts", attempt+1, d.Status, d.Attempts)
+		}
+		wantBody := fmt.Sprintf("reply %d", attempt+1)
+		if d.ResponseStatus != status || d.ResponseBody != wantBody || d.Error == "" {
+			t.Fatalf("attempt %d: recorded (%d, %q, %q), want (%d, %q, an error)",
+				attempt+1, d.ResponseStatus, d.ResponseBody, d.Error, status, wantBody)
+		}
+
+		var job models.Job
+		if err := env.db.First(&job).Error; err != nil {
+			t.Fatal(err)
+		}
+		wantRunAt := before.Add(time.Duration(attempt+1) * time.Hour)
+		if job.State != jobs.StateQueued || job.RunAt.Before(wantRunAt.Add(-time.Second)) {
+			t.Fatalf("attempt %d: job %s at %v, want queued at about %v", attempt+1, job.State, job.RunAt, wantRunAt)
+		}
+	}
+
+	env.work(t)
+	d = env.delivery(t, d.ID)
+	if d.Status != "succeeded" || d.Attempts != 3 || d.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v, want succeeded on attempt 3", d)
+	}
+	if d.ResponseStatus != http.StatusOK || d.ResponseBody != "reply 3" || d.Error != "" {
+		t.Fatalf("recorded (%d, %q, %q), want (200, %q, no error)", d.ResponseStatus, d.ResponseBody, d.Error, "reply 3")
+	}
+
+	reqs := rcv.received()
+	if len(reqs) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(reqs))
+	}
+	for _, req := range reqs {
+		checkWebhookSignature(t, req)
+		if got := req.header.Get("X-Webhook-Delivery"); got != strconv.FormatUint(uint64(d.ID), 10) {
+			t.Fatalf("X-Webhook-Delivery = %q, want %d", got, d.ID)
+		}
+		var envelope webhookEnvelope
+		if err := json.Unmarshal(req.body, &envelope); err != nil || envelope.Event != "post.created" {
+			t.Fatalf("body = %s, want a post.created envelope", req.body)
+		}
+	}
+}
+
+func TestDeliverFailsAfterMaxAttempts(t *testing.T) {
+	env := newWebhookTestEnv(t)
+	rcv := newWebhookReceiver(t, http.StatusInternalServerError)
+	env.subscribe(t, rcv.URL)
+
+	queueWebhooks(env.db, "post.created", map[string]uint{"id": 1})
+	d := env.onlyDelivery(t)
+
+	for range webhookMaxAttempts {
+		env.work(t)
+	}
+
+	d = env.delivery(t, d.ID)
+	if d.Status != "failed" || d.Attempts != webhookMaxAttempts {
+		t.Fatalf("delivery status %q after %d attempts, want failed after %d", d.Status, d.Attempts, webhookMaxAttempts)
+	}
+	wantBody := fmt.Sprintf("reply %d", webhookMaxAttempts)
+	if d.ResponseStatus != http.StatusInternalServerError || d.ResponseBody != wantBody || d.Error == "" {
+		t.Fatalf("recorded (%d, %q, %q), want (500, %q, an error)", d.ResponseStatus, d.ResponseBody, d.Error, wantBody)
+	}
+	if d.DeliveredAt != nil {
+		t.Fatal("failed delivery has deliveredAt set")
+	}
+
+	var left int64
+	env.db.Model(&models.Job{}).Count(&left)
+	if left != 0 {
+		t.Fatalf("%d jobs left once the delivery failed, want 0", left)
+	}
+	if n := len(rcv.received()); n != webhookMaxAttempts {
+		t.Fatalf("receiver got %d requests, want %d", n, webhookMaxAttempts)
+	}
+}
+
+func TestRedeliverCreatesNewDelivery(t *testing.T) {
+	env := newWebhookTestEnv(t)
+	rcv := newWebhookReceiver(t, http.StatusOK)
+	sub := env.subscribe(t, rcv.URL)
+
+	queueWebhooks(env.db, "post.created", map[string]uint{"id": 1})
+	original := env.onlyDelivery(t)
+	env.work(t)
+
+	r := chi.NewRouter()
+	env.c.RegisterRoutes(r)
+	path := fmt.Sprintf("/admin/webhooks/%d/deliveries/%d/redeliver", sub.ID, original.ID)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(fmt.Sprintf(`{"userId":%d}`, env.admin.ID)))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("redeliver = %d %s, want 202", rec.Code, rec.Body)
+	}
+
+	var ds []models.WebhookDelivery
+	if err := env.db.Order("id").Find(&ds).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 {
+		t.Fatalf("%d deliveries after redelivery, want 2", len(ds))
+	}
+	if ds[0].ID != original.ID || ds[0].Status != "succeeded" || ds[0].RedeliveryOfID != nil {
+		t.Fatalf("original delivery changed: %+v", ds[0])
+	}
+	redelivery := ds[1]
+	if redelivery.RedeliveryOfID == nil || *redelivery.RedeliveryOfID != original.ID {
+		t.Fatalf("redeliveryOfId = %v, want %d", redelivery.RedeliveryOfID, original.ID)
+	}
+	if redelivery.Status != "pending" || redelivery.Attempts != 0 || redelivery.Payload != original.Payload {
+		t.Fatalf("redelivery = %+v, want a pending copy of the original", redelivery)
+	}
+
+	env.work(t)
+	if d := env.delivery(t, redelivery.ID); d.Status != "succeeded" {
+		t.Fatalf("redelivery status %q, want succeeded", d.Status)
+	}
+	reqs := rcv.received()
+	if len(reqs) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(reqs))
+	}
+	if got := reqs[1].header.Get("X-Webhook-Delivery"); got != strconv.FormatUint(uint64(redelivery.ID), 10) {
+		t.Fatalf("X-Webhook-Delivery = %q, want %d", got, redelivery.ID)
+	}
+	if !bytes.Equal(reqs[0].body, reqs[1].body) {
+		t.Fatal("redelivery sent a different body")
+	}
+	checkWebhookSignature(t, reqs[1])
+}
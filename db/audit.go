@@ -0,0 +1,21 @@
+package db
+
+import "gorm.io/gorm"
+
+// EnsureAuditLogAppendOnly installs a trigger that rejects UPDATE and DELETE
+// on audit_log, so history can't be rewritten even with direct DB access
+// through the application role.
+func EnsureAuditLogAppendOnly(gdb *gorm.DB) error {
+	return gdb.Exec(`
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+`).Error
+}
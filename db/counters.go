@@ -0,0 +1,38 @@
+package db
+
+import "gorm.io/gorm"
+
+// ReconcileCounters recomputes topics.post_count/last_post_at and
+// posts.comment_count/last_comment_at from the rows they summarize, only
+// touching rows that drifted. It returns how many topics and posts changed.
+func ReconcileCounters(gdb *gorm.DB) (topics, posts int64, err error) {
+	res := gdb.Exec(`
+		UPDATE topics t
+		SET post_count = s.n, last_post_at = s.last
+		FROM (
+			SELECT topics.id, COUNT(posts.id) AS n, MAX(posts.created_at) AS last
+			FROM topics LEFT JOIN posts ON posts.topic_id = topics.id
+			GROUP BY topics.id
+		) s
+		WHERE t.id = s.id
+		  AND (t.post_count <> s.n OR t.last_post_at IS DISTINCT FROM s.last)`)
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	topics = res.RowsAffected
+
+	res = gdb.Exec(`
+		UPDATE posts p
+		SET comment_count = s.n, last_comment_at = s.last
+		FROM (
+			SELECT posts.id, COUNT(comments.id) AS n, MAX(comments.created_at) AS last
+			FROM posts LEFT JOIN comments ON comments.post_id = posts.id
+			GROUP BY posts.id
+		) s
+		WHERE p.id = s.id
+		  AND (p.comment_count <> s.n OR p.last_comment_at IS DISTINCT FROM s.last)`)
+	if res.Error != nil {
+		return topics, 0, res.Error
+	}
+	return topics, res.RowsAffected, nil
+}
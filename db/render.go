@@ -0,0 +1,44 @@
+package db
+
+import (
+	"CVWO-Backend/markdown"
+	"CVWO-Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// BackfillRenderedBodies fills rendered_body for posts and comments written
+// before bodies were rendered on save. It scans both tables, so it runs as
+// the backfill-rendered command rather than on every start; until then
+// responses render those bodies on the fly.
+func BackfillRenderedBodies(gdb *gorm.DB) error {
+	var posts []models.Post
+	if err := gdb.
+		Select("id", "body").
+		Where("rendered_body IS NULL OR rendered_body = ''").
+		FindInBatches(&posts, 200, func(tx *gorm.DB, _ int) error {
+			for _, p := range posts {
+				if err := gdb.Model(&models.Post{}).Where("id = ?", p.ID).
+					UpdateColumn("rendered_body", markdown.Render(p.Body)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error; err != nil {
+		return err
+	}
+
+	var comments []models.Comment
+	return gdb.
+		Select("id", "body").
+		Where("rendered_body IS NULL OR rendered_body = ''").
+		FindInBatches(&comments, 200, func(tx *gorm.DB, _ int) error {
+			for _, c := range comments {
+				if err := gdb.Model(&models.Comment{}).Where("id = ?", c.ID).
+					UpdateColumn("rendered_body", markdown.Render(c.Body)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -7,8 +7,8 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func SeedDefaultTopics(gdb *gorm.DB) error {
-	defaultTopics := []models.Topic{
+func defaultTopics() []models.Topic {
+	return []models.Topic{
 		{Title: "Tech", Description: "Gadgets, programming, AI, and tech news."},
 		{Title: "Games", Description: "Gaming discussion: PC/console/mobile, esports, and releases."},
 		{Title: "Lifestyle", Description: "Daily life, productivity, wellness, and habits."},
@@ -16,6 +16,20 @@ func SeedDefaultTopics(gdb *gorm.DB) error {
 		{Title: "Automotive", Description: "Cars, bikes, mods, reviews, and maintenance."},
 		{Title: "Culture", Description: "Pop culture, trends, media, and society."},
 	}
+}
+
+// DefaultTopicTitles lists the topics created by SeedDefaultTopics.
+func DefaultTopicTitles() []string {
+	topics := defaultTopics()
+	titles := make([]string, 0, len(topics))
+	for _, t := range topics {
+		titles = append(titles, t.Title)
+	}
+	return titles
+}
+
+func SeedDefaultTopics(gdb *gorm.DB) error {
+	defaultTopics := defaultTopics()
 
 	return gdb.
 		Clauses(clause.OnConflict{
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txPool is what gorm keeps in Statement.ConnPool inside a transaction.
+type txPool interface {
+	gorm.ConnPool
+	gorm.TxCommitter
+}
+
+// afterCommitPool wraps a transaction's connection so work can be deferred
+// until it commits. gorm's Transaction commits through the same Statement
+// the callback was handed, so swapping the pool on that Statement is enough
+// to see the commit. A rollback drops the deferred work.
+type afterCommitPool struct {
+	txPool
+
+	mu    sync.Mutex
+	funcs []func()
+}
+
+func (p *afterCommitPool) Commit() error {
+	if err := p.txPool.Commit(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	funcs := p.funcs
+	p.funcs = nil
+	p.mu.Unlock()
+	for _, f := range funcs {
+		f()
+	}
+	return nil
+}
+
+// afterCommit runs f once tx commits, or straight away when tx is not a
+// transaction. tx must be the handle passed to DB.Transaction (or one
+// derived from it after the first afterCommit call); a Statement cloned
+// from it earlier doesn't see the commit.
+func afterCommit(tx *gorm.DB, f func()) {
+	switch pool := tx.Statement.ConnPool.(type) {
+	case *afterCommitPool:
+		pool.mu.Lock()
+		pool.funcs = append(pool.funcs, f)
+		pool.mu.Unlock()
+	case txPool:
+		tx.Statement.ConnPool = &afterCommitPool{txPool: pool, funcs: []func(){f}}
+	default:
+		f()
+	}
+}
@@ -0,0 +1,250 @@
+// Package jobs runs background work from a Postgres-backed queue. Jobs are
+// enqueued inside the caller's transaction, so they only exist if the work
+// that produced them commits, and are claimed with FOR UPDATE SKIP LOCKED so
+// any number of workers and instances can share the table.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"CVWO-Backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDead    = "dead"
+)
+
+// Handler runs one job against the queue's database. A returned error
+// retries the job until it runs out of attempts.
+type Handler func(ctx context.Context, db *gorm.DB, payload json.RawMessage) error
+
+type Queue struct {
+	DB *gorm.DB
+
+	Workers      int
+	PollInterval time.Duration
+	// Lease is how long a job may stay running before it is assumed lost
+	// (its worker died) and handed out again.
+	Lease   time.Duration
+	Backoff func(attempts int) time.Duration
+
+	// Sync runs due jobs as soon as the enqueuing transaction commits
+	// instead of storing them, so tests see their effects once the request
+	// returns. Handler errors are logged, not retried, and never reach the
+	// enqueuer. Jobs scheduled for later are still stored and left to Run.
+	Sync bool
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewQueue(db *gorm.DB) *Queue {
+	return &Queue{
+		DB:           db,
+		Workers:      4,
+		PollInterval: time.Second,
+		Lease:        5 * time.Minute,
+		Backoff:      DefaultBackoff,
+		handlers:     map[string]Handler{},
+	}
+}
+
+// DefaultBackoff waits 10s after the first failure and doubles from there,
+// up to an hour.
+func DefaultBackoff(attempts int) time.Duration {
+	d := 10 * time.Second
+	for i := 1; i < attempts && d < time.Hour; i++ {
+		d *= 2
+	}
+	return min(d, time.Hour)
+}
+
+// Register sets the handler for kind, replacing any earlier one.
+func (q *Queue) Register(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+func (q *Queue) handler(kind string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[kind]
+	return h, ok
+}
+
+type Option func(*models.Job)
+
+// RunAt schedules the job for t instead of now.
+func RunAt(t time.Time) Option {
+	return func(j *models.Job) { j.RunAt = t }
+}
+
+// MaxAttempts overrides the default of 5 attempts before a job is dead.
+func MaxAttempts(n int) Option {
+	return func(j *models.Job) { j.MaxAttempts = n }
+}
+
+// Enqueue stores a job in tx. payload is marshalled to JSON. In Sync mode a
+// due job is instead run after tx commits; see afterCommit for which handles
+// qualify.
+func (q *Queue) Enqueue(tx *gorm.DB, kind string, payload any, opts ...Option) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	job := models.Job{
+		Kind:        kind,
+		Payload:     string(raw),
+		State:       StateQueued,
+		MaxAttempts: 5,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+
+	if q.Sync && !job.RunAt.After(time.Now()) {
+		if _, ok := q.handler(kind); !ok {
+			return fmt.Errorf("jobs: no handler for %q", kind)
+		}
+		afterCommit(tx, func() {
+			if err := q.run(context.Background(), job); err != nil {
+				log.Printf("jobs: sync %s job failed: %v", kind, err)
+			}
+		})
+		return nil
+	}
+
+	return tx.Create(&job).Error
+}
+
+// Run starts the workers and blocks until ctx is done and every job that
+// was already running has finished.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range max(q.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *Queue) worker(ctx context.Context) {
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain due jobs before waiting for the next tick.
+		for ctx.Err() == nil {
+			worked, err := q.Work(ctx)
+			if err != nil {
+				log.Printf("jobs: %v", err)
+				break
+			}
+			if !worked {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Work claims and runs one due job. It reports false when there was nothing
+// to do. Running jobs are not cancelled with ctx so shutdown lets them
+// finish.
+func (q *Queue) Work(ctx context.Context) (bool, error) {
+	job, err := q.claim()
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	runErr := q.run(context.WithoutCancel(ctx), *job)
+	if runErr == nil {
+		return true, q.DB.Delete(&models.Job{}, job.ID).Error
+	}
+
+	updates := map[string]any{
+		"state":      StateQueued,
+		"run_at":     time.Now().Add(q.Backoff(job.Attempts)),
+		"locked_at":  nil,
+		"last_error": runErr.Error(),
+	}
+	if job.Attempts >= job.MaxAttempts || errors.Is(runErr, errNoHandler) {
+		updates["state"] = StateDead
+		log.Printf("jobs: %s job %d is dead after %d attempts: %v", job.Kind, job.ID, job.Attempts, runErr)
+	}
+	return true, q.DB.Model(&models.Job{}).Where("id = ?", job.ID).Updates(updates).Error
+}
+
+var errNoHandler = errors.New("no handler registered")
+
+func (q *Queue) run(ctx context.Context, job models.Job) (err error) {
+	h, ok := q.handler(job.Kind)
+	if !ok {
+		return errNoHandler
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(ctx, q.DB, json.RawMessage(job.Payload))
+}
+
+// claim marks the next due job as running and returns it, or nil if none is
+// due. Jobs whose lease has expired count as due again.
+func (q *Queue) claim() (*models.Job, error) {
+	now := time.Now()
+	var jobs []models.Job
+	err := q.DB.Raw(`
+		UPDATE jobs
+		SET state = ?, attempts = attempts + 1, locked_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (state = ? AND run_at <= ?) OR (state = ? AND locked_at < ?)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		StateRunning, now, now,
+		StateQueued, now, StateRunning, now.Add(-q.Lease),
+	).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+var defaultQueue *Queue
+
+// SetDefault sets the queue used by the package-level Enqueue.
+func SetDefault(q *Queue) { defaultQueue = q }
+
+// Enqueue stores a job on the default queue; see Queue.Enqueue.
+func Enqueue(tx *gorm.DB, kind string, payload any, opts ...Option) error {
+	if defaultQueue == nil {
+		return errors.New("jobs: no default queue")
+	}
+	return defaultQueue.Enqueue(tx, kind, payload, opts...)
+}
@@ -0,0 +1,330 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"CVWO-Backend/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakePool stands in for a database connection in Sync mode tests, which
+// only need transactions to begin, commit and roll back.
+type fakePool struct{}
+
+func (fakePool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("fakePool: no SQL")
+}
+func (fakePool) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, errors.New("fakePool: no SQL")
+}
+func (fakePool) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errors.New("fakePool: no SQL")
+}
+func (fakePool) QueryRowContext(context.Context, string, ...any) *sql.Row { return nil }
+
+func (fakePool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{}, nil
+}
+
+type fakeTx struct{ fakePool }
+
+func (*fakeTx) Commit() error   { return nil }
+func (*fakeTx) Rollback() error { return nil }
+
+func newSyncQueue(t *testing.T) *Queue {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: fakePool{}}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewQueue(db)
+	q.Sync = true
+	return q
+}
+
+type syncCall struct {
+	db      *gorm.DB
+	payload string
+}
+
+func recordCalls(q *Queue, kind string, err error) *[]syncCall {
+	var calls []syncCall
+	q.Register(kind, func(_ context.Context, db *gorm.DB, payload json.RawMessage) error {
+		calls = append(calls, syncCall{db: db, payload: string(payload)})
+		return err
+	})
+	return &calls
+}
+
+func TestSyncRunsAfterCommit(t *testing.T) {
+	q := newSyncQueue(t)
+	calls := recordCalls(q, "test", nil)
+
+	err := q.DB.Transaction(func(tx *gorm.DB) error {
+		if err := q.Enqueue(tx, "test", map[string]int{"n": 1}); err != nil {
+			return err
+		}
+		if err := q.Enqueue(tx, "test", map[string]int{"n": 2}); err != nil {
+			return err
+		}
+		if len(*calls) != 0 {
+			t.Fatal("handler ran before the transaction committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*calls) != 2 {
+		t.Fatalf("handler ran %d times, want 2", len(*calls))
+	}
+	for i, want := range []string{`{"n":1}`, `{"n":2}`} {
+		c := (*calls)[i]
+		if c.payload != want {
+			t.Errorf("call %d payload = %s, want %s", i, c.payload, want)
+		}
+		if c.db != q.DB {
+			t.Errorf("call %d got a database other than the queue's", i)
+		}
+	}
+}
+
+func TestSyncSkipsRolledBackJobs(t *testing.T) {
+	q := newSyncQueue(t)
+	calls := recordCalls(q, "test", nil)
+
+	err := q.DB.Transaction(func(tx *gorm.DB) error {
+		if err := q.Enqueue(tx, "test", nil); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("transaction did not fail")
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("handler ran %d times for a rolled-back transaction", len(*calls))
+	}
+}
+
+func TestSyncHandlerFailuresDoNotReachEnqueuer(t *testing.T) {
+	q := newSyncQueue(t)
+	calls := recordCalls(q, "fails", errors.New("boom"))
+	q.Register("panics", func(context.Context, *gorm.DB, json.RawMessage) error {
+		panic("boom")
+	})
+
+	err := q.DB.Transaction(func(tx *gorm.DB) error {
+		if err := q.Enqueue(tx, "fails", nil); err != nil {
+			return err
+		}
+		return q.Enqueue(tx, "panics", nil)
+	})
+	if err != nil {
+		t.Fatalf("transaction failed with %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("failing handler ran %d times, want 1", len(*calls))
+	}
+}
+
+func TestSyncOutsideTransactionRunsImmediately(t *testing.T) {
+	q := newSyncQueue(t)
+	calls := recordCalls(q, "test", nil)
+
+	if err := q.Enqueue(q.DB, "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("handler ran %d times, want 1", len(*calls))
+	}
+}
+
+func TestSyncUnknownKind(t *testing.T) {
+	q := newSyncQueue(t)
+	if err := q.Enqueue(q.DB, "missing", nil); err == nil {
+		t.Fatal("enqueueing a kind with no handler succeeded")
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := DefaultBackoff(tt.attempts); got != tt.want {
+			t.Errorf("DefaultBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// newDBQueue returns a queue on the database named by TEST_DATABASE_URL,
+// with the jobs table emptied, or skips the test when it isn't set.
+func newDBQueue(t *testing.T) *Queue {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("DELETE FROM jobs").Error; err != nil {
+		t.Fatal(err)
+	}
+	return NewQueue(db)
+}
+
+func loadJob(t *testing.T, q *Queue) models.Job {
+	t.Helper()
+	var job models.Job
+	if err := q.DB.First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// makeDue moves every queued job's run_at into the past.
+func makeDue(t *testing.T, q *Queue) {
+	t.Helper()
+	err := q.DB.Model(&models.Job{}).Where("state = ?", StateQueued).
+		Update("run_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func work(t *testing.T, q *Queue) bool {
+	t.Helper()
+	worked, err := q.Work(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return worked
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	q := newDBQueue(t)
+	q.Backoff = func(attempts int) time.Duration { return time.Duration(attempts) * time.Hour }
+	failures := 2
+	q.Register("flaky", func(context.Context, *gorm.DB, json.RawMessage) error {
+		if failures > 0 {
+			failures--
+			return errors.New("try again")
+		}
+		return nil
+	})
+
+	if err := q.Enqueue(q.DB, "flaky", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		if !work(t, q) {
+			t.Fatalf("attempt %d: no job was due", attempt)
+		}
+		job := loadJob(t, q)
+		if job.State != StateQueued || job.Attempts != attempt || job.LastError != "try again" {
+			t.Fatalf("attempt %d: job = %+v", attempt, job)
+		}
+		wantRunAt := before.Add(time.Duration(attempt) * time.Hour)
+		if job.RunAt.Before(wantRunAt.Add(-time.Second)) || job.RunAt.After(wantRunAt.Add(time.Minute)) {
+			t.Fatalf("attempt %d: run_at = %v, want about %v", attempt, job.RunAt, wantRunAt)
+		}
+		if work(t, q) {
+			t.Fatalf("attempt %d: job ran again before its backoff", attempt)
+		}
+		makeDue(t, q)
+	}
+
+	if !work(t, q) {
+		t.Fatal("retried job was not due")
+	}
+	var count int64
+	q.DB.Model(&models.Job{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d jobs left after success, want 0", count)
+	}
+}
+
+func TestDeadAfterMaxAttempts(t *testing.T) {
+	q := newDBQueue(t)
+	q.Backoff = func(int) time.Duration { return 0 }
+	q.Register("broken", func(context.Context, *gorm.DB, json.RawMessage) error {
+		return errors.New("broken")
+	})
+
+	if err := q.Enqueue(q.DB, "broken", nil, MaxAttempts(3)); err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		makeDue(t, q)
+		if !work(t, q) {
+			t.Fatalf("attempt %d: no job was due", attempt)
+		}
+	}
+
+	job := loadJob(t, q)
+	if job.State != StateDead || job.Attempts != 3 || job.LastError != "broken" {
+		t.Fatalf("job = %+v, want dead after 3 attempts", job)
+	}
+	makeDue(t, q)
+	if work(t, q) {
+		t.Fatal("dead job was claimed again")
+	}
+}
+
+func TestRunAtSchedulesJob(t *testing.T) {
+	q := newDBQueue(t)
+	ran := 0
+	q.Register("later", func(context.Context, *gorm.DB, json.RawMessage) error {
+		ran++
+		return nil
+	})
+
+	// Sync mode leaves future jobs to the workers too.
+	q.Sync = true
+	if err := q.Enqueue(q.DB, "later", nil, RunAt(time.Now().Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 0 {
+		t.Fatal("Sync mode ran a job scheduled for later")
+	}
+	if work(t, q) {
+		t.Fatal("job ran before its run_at")
+	}
+
+	makeDue(t, q)
+	if !work(t, q) {
+		t.Fatal("job was not claimed once due")
+	}
+	if ran != 1 {
+		t.Fatalf("handler ran %d times, want 1", ran)
+	}
+}
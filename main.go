@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"CVWO-Backend/controllers"
 	"CVWO-Backend/db"
+	"CVWO-Backend/jobs"
 	"CVWO-Backend/models"
+	"CVWO-Backend/realtime"
+	"CVWO-Backend/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -34,53 +43,246 @@ func main() {
 		log.Fatalf("db connect error: %v", err)
 	}
 
+	if err := gdb.SetupJoinTable(&models.Post{}, "Tags", &models.PostTag{}); err != nil {
+		log.Fatalf("db join table error: %v", err)
+	}
+
+	// Counter columns start at zero when first added; see ReconcileCounters below.
+	hadCounters := gdb.Migrator().HasColumn(&models.Post{}, "comment_count")
+
 	if err := gdb.AutoMigrate(
 		&models.Topic{},
 		&models.User{},
 		&models.Post{},
 		&models.Comment{},
+		&models.Report{},
+		&models.ReportEntry{},
+		&models.ModerationLog{},
+		&models.Ban{},
+		&models.AuditLog{},
+		&models.TopicModerator{},
+		&models.Tag{},
+		&models.Mention{},
+		&models.Notification{},
+		&models.Attachment{},
+		&models.Bookmark{},
+		&models.PostRead{},
+		&models.TopicFollow{},
+		&models.UserFollow{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.UserBlock{},
+		&models.UserMute{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollBallot{},
+		&models.PollBallotChoice{},
+		&models.Draft{},
+		&models.Job{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 	); err != nil {
 		log.Fatalf("db migrate error: %v", err)
 	}
 
+	if err := db.EnsureAuditLogAppendOnly(gdb); err != nil {
+		log.Fatalf("audit log trigger error: %v", err)
+	}
+
+	if err := db.EnsurePostSortIndexes(gdb); err != nil {
+		log.Fatalf("post index error: %v", err)
+	}
+
+	// The migration that adds the counters fills them once. It runs before
+	// the commands below in case one of them is the first run on the new
+	// schema; later starts see the columns and skip it.
+	if !hadCounters {
+		reconcileCounters(gdb)
+	}
+
+	// One-shot maintenance commands run after migrations and exit:
+	//   reconcile-counters  repairs post/comment counter drift
+	//   backfill-rendered   renders bodies stored before rendering on save
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile-counters":
+			if hadCounters {
+				reconcileCounters(gdb)
+			}
+		case "backfill-rendered":
+			if err := db.BackfillRenderedBodies(gdb); err != nil {
+				log.Fatalf("render backfill error: %v", err)
+			}
+			log.Printf("rendered bodies backfilled")
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+		return
+	}
+
 	if err := db.SeedDefaultTopics(gdb); err != nil {
 	log.Fatalf("seed topics error: %v", err)
 	}
 
+	var broker realtime.Broker = realtime.NewMemoryBroker()
+	if os.Getenv("REALTIME_BROKER") == "postgres" {
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			log.Fatalf("db handle error: %v", err)
+		}
+		pgBroker := realtime.NewPGBroker(sqlDB, dsn)
+		go pgBroker.Listen(context.Background())
+		broker = pgBroker
+	}
+
+	presenceHub := realtime.NewPresenceHub(60*time.Second, 6*time.Second)
+	go presenceHub.Run(context.Background(), 5*time.Second)
+
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "./uploads"
+	}
+	attachmentStore, err := storage.NewLocalStorage(uploadDir)
+	if err != nil {
+		log.Fatalf("attachment storage error: %v", err)
+	}
+
+	signingKey := []byte(os.Getenv("ATTACHMENT_SIGNING_KEY"))
+	if len(signingKey) == 0 {
+		// Links signed with a random key stop working on restart and are not
+		// valid across instances, which is fine for local development only.
+		log.Println("ATTACHMENT_SIGNING_KEY not set, using a random key")
+		signingKey = make([]byte, 32)
+		_, _ = rand.Read(signingKey)
+	}
+	urlSigner := storage.NewURLSigner(signingKey, time.Hour)
+
+	queue := jobs.NewQueue(gdb)
+	// JOBS_SYNC=true runs jobs as soon as the request that enqueues them
+	// commits, which keeps tests and local debugging deterministic.
+	queue.Sync = os.Getenv("JOBS_SYNC") == "true"
+	jobs.SetDefault(queue)
+
+	allowedOrigins := []string{
+		"https://cvwo-forum-frontend-xyb2.onrender.com",
+		"http://localhost:5173",
+	}
+
+	// Feed links point at the frontend; FRONTEND_URL overrides the deployed one.
+	siteURL := os.Getenv("FRONTEND_URL")
+	if siteURL == "" {
+		siteURL = allowedOrigins[0]
+	}
+
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
 	
 	r.Use(cors.Handler(cors.Options{
-	  AllowedOrigins: []string{
-	    "https://cvwo-forum-frontend-xyb2.onrender.com",
-	    "http://localhost:5173",
-	  },
-	  AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+	  AllowedOrigins: allowedOrigins,
+	  AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 	  AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
 	  AllowCredentials: false,
 	  MaxAge: 300,
 	}))
 
+	attachmentsController := controllers.NewAttachmentsController(gdb, attachmentStore, urlSigner)
+	auditController := controllers.NewAuditController(gdb)
 	authController := controllers.NewAuthController(gdb)
-	commentsController := controllers.NewCommentsController(gdb)
-	postsController := controllers.NewPostsController(gdb)
+	bansController := controllers.NewBansController(gdb)
+	blocksController := controllers.NewBlocksController(gdb)
+	bookmarksController := controllers.NewBookmarksController(gdb, urlSigner)
+	commentsController := controllers.NewCommentsController(gdb, broker, urlSigner)
+	conversationsController := controllers.NewConversationsController(gdb, broker)
+	draftsController := controllers.NewDraftsController(gdb, broker, urlSigner)
+	eventsController := controllers.NewEventsController(gdb, broker)
+	feedController := controllers.NewFeedController(gdb, urlSigner)
+	liveController := controllers.NewLiveController(gdb, presenceHub, allowedOrigins)
+	jobsController := controllers.NewJobsController(gdb)
+	notificationsController := controllers.NewNotificationsController(gdb, broker)
+	pollsController := controllers.NewPollsController(gdb, broker)
+	postReadsController := controllers.NewPostReadsController(gdb)
+	postsController := controllers.NewPostsController(gdb, broker, urlSigner)
+	reportsController := controllers.NewReportsController(gdb, broker)
+	syndicationController := controllers.NewSyndicationController(gdb, siteURL)
+	tagsController := controllers.NewTagsController(gdb)
+	topicFollowsController := controllers.NewTopicFollowsController(gdb)
+	topicModeratorsController := controllers.NewTopicModeratorsController(gdb)
 	topicsController := controllers.NewTopicsController(gdb)
+	usersController := controllers.NewUsersController(gdb)
+	webhooksController := controllers.NewWebhooksController(gdb)
 
+	attachmentsController.RegisterRoutes(r)
+	auditController.RegisterRoutes(r)
 	authController.RegisterRoutes(r)
+	bansController.RegisterRoutes(r)
+	blocksController.RegisterRoutes(r)
+	bookmarksController.RegisterRoutes(r)
 	commentsController.RegisterRoutes(r)
+	conversationsController.RegisterRoutes(r)
+	draftsController.RegisterRoutes(r)
+	eventsController.RegisterRoutes(r)
+	feedController.RegisterRoutes(r)
+	jobsController.RegisterRoutes(r)
+	liveController.RegisterRoutes(r)
+	notificationsController.RegisterRoutes(r)
+	pollsController.RegisterRoutes(r)
+	postReadsController.RegisterRoutes(r)
 	postsController.RegisterRoutes(r)
+	reportsController.RegisterRoutes(r)
+	syndicationController.RegisterRoutes(r)
+	tagsController.RegisterRoutes(r)
+	topicFollowsController.RegisterRoutes(r)
+	topicModeratorsController.RegisterRoutes(r)
 	topicsController.RegisterRoutes(r)
+	usersController.RegisterRoutes(r)
+	webhooksController.RegisterRoutes(r)
+
+	attachmentsController.RegisterJobs(queue)
+	draftsController.RegisterJobs(queue)
+	notificationsController.RegisterJobs(queue)
+	webhooksController.RegisterJobs(queue)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workersDone := make(chan struct{})
+	go func() {
+		queue.Run(ctx)
+		close(workersDone)
+	}()
 
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: r,
 	}
 
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("server shutdown: %v", err)
+		}
+	}()
+
 	fmt.Printf("Server running on port %s\n", port)
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("server error: %v", err)
 	}
+
+	// Let jobs that were already running finish before exiting.
+	<-workersDone
+}
+
+func reconcileCounters(gdb *gorm.DB) {
+	topics, posts, err := db.ReconcileCounters(gdb)
+	if err != nil {
+		log.Fatalf("reconcile counters error: %v", err)
+	}
+	log.Printf("reconciled counters on %d topics and %d posts", topics, posts)
 }
@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderXSS(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"script block", "<script>alert(1)</script>", "\n"},
+		{"inline script", "hi <script>alert(1)</script>", "<p>hi alert(1)</p>\n"},
+		{"javascript href", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"mixed case javascript href", "[x](JaVaScRiPt:alert(1))", "<p>x</p>\n"},
+		{"data href", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		{"decimal entity scheme", "[x](&#106;avascript:alert(1))", "<p>x</p>\n"},
+		{"hex entity scheme", "[x](&#x6A;avascript:alert(1))", "<p>x</p>\n"},
+		{"entity tab in scheme", "[x](java&#x09;script:alert(1))", "<p>x</p>\n"},
+		{"javascript image", "![x](javascript:alert(1))", "<p><img alt=\"x\"></p>\n"},
+		{"mixed case javascript image", "![x](JaVaScRiPt:alert(1))", "<p><img alt=\"x\"></p>\n"},
+		{"data image", "![x](data:image/png;base64,AAAA)", "<p><img alt=\"x\"></p>\n"},
+		{"raw img onerror", "<img src=x onerror=alert(1)>", "\n"},
+		{"raw anchor onclick", `<a href="https://e.com" onclick="alert(1)">x</a>`, "<p>x</p>\n"},
+		{"raw iframe", `<iframe src="https://evil.example"></iframe>`, "\n"},
+		{"raw style", "<style>body{}</style>", "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeXSS(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"script", "<script>alert(1)</script><p>x</p>", "<p>x</p>"},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, "x"},
+		{"mixed case javascript href", `<a href="JaVaScRiPt:alert(1)">x</a>`, "x"},
+		{"data href", `<a href="data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`, "x"},
+		{"decimal entity scheme", `<a href="&#106;avascript:alert(1)">x</a>`, "x"},
+		{"entity tab in scheme", `<a href="jav&#x09;ascript:alert(1)">x</a>`, "x"},
+		{"javascript image", `<img src="JaVaScRiPt:alert(1)">`, ""},
+		{"data image", `<img src="data:image/png;base64,AAAA">`, ""},
+		{"onerror", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png">`},
+		{"onclick", `<a href="https://example.com" onclick="alert(1)">x</a>`,
+			`<a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">x</a>`},
+		{"svg onload", "<svg onload=alert(1)>", ""},
+		{"iframe", `<iframe src="https://evil.example"></iframe>`, ""},
+		{"style element", "<style>body{color:red}</style><p>x</p>", "<p>x</p>"},
+		{"style attribute", `<p style="color:red">x</p>`, "<p>x</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.html); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"markdown link", "[x](https://example.com)",
+			`<p><a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">x</a></p>` + "\n"},
+		{"autolink", "https://example.com",
+			`<p><a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">https://example.com</a></p>` + "\n"},
+		{"mailto", "[x](mailto:a@example.com)",
+			`<p><a href="mailto:a@example.com" rel="nofollow noreferrer">x</a></p>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.src)
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+			if !strings.Contains(got, "nofollow") {
+				t.Errorf("Render(%q) link is missing rel=nofollow", tt.src)
+			}
+		})
+	}
+}
+
+func TestRenderKeepsFormatting(t *testing.T) {
+	src := "**bold** and `code`\n\n- [x] done\n\n| a |\n|:-:|\n| b |\n"
+	got := Render(src)
+	for _, want := range []string{
+		"<strong>bold</strong>",
+		"<code>code</code>",
+		`<input checked="" disabled="" type="checkbox">`,
+		`<th align="center">a</th>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render(%q) = %q, missing %q", src, got, want)
+		}
+	}
+}
+
+func TestCodeRanges(t *testing.T) {
+	src := "a `b` c\n\n```\nd\n```\n\n    e\n"
+	var got []string
+	for _, r := range CodeRanges(src) {
+		got = append(got, src[r[0]:r[1]])
+	}
+	want := []string{"b", "d\n", "e\n"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("CodeRanges(%q) covers %q, want %q", src, got, want)
+	}
+}
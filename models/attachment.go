@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Attachment is an uploaded file belonging to a post (PostID set) or a
+// comment (CommentID set). The bytes live in storage under StorageKey;
+// images also get a JPEG thumbnail under ThumbnailKey.
+type Attachment struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	UserID      uint   `gorm:"not null;index" json:"userId"`
+	PostID      *uint  `gorm:"index" json:"postId,omitempty"`
+	CommentID   *uint  `gorm:"index" json:"commentId,omitempty"`
+	FileName    string `gorm:"size:255;not null" json:"fileName"`
+	ContentType string `gorm:"size:100;not null" json:"contentType"`
+	Size        int64  `gorm:"not null" json:"size"`
+
+	StorageKey   string `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	ThumbnailKey string `gorm:"size:255" json:"-"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	User    User     `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Post    *Post    `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Comment *Comment `gorm:"foreignKey:CommentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
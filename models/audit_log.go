@@ -0,0 +1,24 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog is an append-only record of privileged changes. Actor and target
+// are stored as plain IDs so entries outlive the rows they describe.
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    uint            `gorm:"not null;index" json:"actorId"`
+	Action     string          `gorm:"size:32;not null;index" json:"action"`
+	TargetType string          `gorm:"size:16;not null;index:idx_audit_log_target" json:"targetType"`
+	TargetID   uint            `gorm:"not null;index:idx_audit_log_target" json:"targetId"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after"`
+	RequestID  string          `gorm:"size:64;index" json:"requestId"`
+	CreatedAt  time.Time       `gorm:"index" json:"createdAt"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
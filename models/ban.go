@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Ban stops a user from posting. A nil TopicID bans the user everywhere
+// (including login); a nil ExpiresAt makes the ban permanent.
+type Ban struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"userId"`
+	TopicID        *uint      `gorm:"index" json:"topicId,omitempty"`
+	Reason         string     `gorm:"type:text;not null" json:"reason"`
+	IssuedByUserID uint       `gorm:"not null;index" json:"issuedByUserId"`
+	ExpiresAt      *time.Time `gorm:"index" json:"expiresAt,omitempty"`
+
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+	RevokedByUserID *uint      `json:"revokedByUserId,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	User     User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Topic    *Topic `gorm:"foreignKey:TopicID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	IssuedBy User   `gorm:"foreignKey:IssuedByUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
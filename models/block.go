@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserBlock stops BlockedID from replying to, mentioning or messaging
+// BlockerID. Blocked users' content is also hidden from the blocker.
+type UserBlock struct {
+	BlockerID uint      `gorm:"primaryKey;check:chk_user_blocks_not_self,blocker_id <> blocked_id" json:"blockerId"`
+	BlockedID uint      `gorm:"primaryKey;index" json:"blockedId"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Blocker User `gorm:"foreignKey:BlockerID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Blocked User `gorm:"foreignKey:BlockedID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// UserMute hides MutedID's posts and comments from MuterID without
+// restricting what MutedID can do.
+type UserMute struct {
+	MuterID   uint      `gorm:"primaryKey;check:chk_user_mutes_not_self,muter_id <> muted_id" json:"muterId"`
+	MutedID   uint      `gorm:"primaryKey;index" json:"mutedId"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Muter User `gorm:"foreignKey:MuterID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Muted User `gorm:"foreignKey:MutedID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Bookmark is a post a user has saved to come back to.
+type Bookmark struct {
+	UserID    uint      `gorm:"primaryKey;index:idx_bookmarks_user_created,priority:1" json:"userId"`
+	PostID    uint      `gorm:"primaryKey;index" json:"postId"`
+	CreatedAt time.Time `gorm:"index:idx_bookmarks_user_created,priority:2" json:"createdAt"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Post Post `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
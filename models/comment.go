@@ -5,14 +5,18 @@ import "time"
 type Comment struct {
 	ID     uint `gorm:"primaryKey" json:"id"`
 	PostID uint `gorm:"not null;index" json:"postId"`
-	UserID uint `gorm:"not null;index" json:"userId"`
+	UserID uint `gorm:"not null;index;index:idx_comments_user_created,priority:1" json:"userId"`
 
-	Body string `gorm:"type:text;not null" json:"body"`
+	Body         string `gorm:"type:text;not null" json:"body"`
+	RenderedBody string `gorm:"type:text" json:"-"`
 
-	CreatedAt time.Time  `json:"createdAt"`
+	CreatedAt time.Time  `gorm:"index:idx_comments_user_created,priority:2" json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	EditedAt  *time.Time `gorm:"index" json:"editedAt,omitempty"`
 
 	Post Post `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Mentions    []Mention    `gorm:"foreignKey:CommentID" json:"-"`
+	Attachments []Attachment `gorm:"foreignKey:CommentID" json:"-"`
 }
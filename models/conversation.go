@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Conversation is a private message thread between two or more users.
+type Conversation struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	CreatedByUserID uint       `gorm:"not null;index" json:"createdByUserId"`
+	LastMessageAt   *time.Time `gorm:"index" json:"lastMessageAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	CreatedByUser User                      `gorm:"foreignKey:CreatedByUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Participants  []ConversationParticipant `json:"-"`
+	Messages      []Message                 `json:"-"`
+}
+
+// ConversationParticipant is a user's membership in a conversation.
+// LastReadMessageID is their read receipt: every message up to and
+// including it has been seen.
+type ConversationParticipant struct {
+	ConversationID    uint       `gorm:"primaryKey" json:"conversationId"`
+	UserID            uint       `gorm:"primaryKey;index" json:"userId"`
+	LastReadMessageID *uint      `json:"lastReadMessageId,omitempty"`
+	LastReadAt        *time.Time `json:"lastReadAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Conversation Conversation `gorm:"foreignKey:ConversationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User         User         `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+type Message struct {
+	ID             uint   `gorm:"primaryKey;index:idx_messages_conversation_id,priority:2" json:"id"`
+	ConversationID uint   `gorm:"not null;index:idx_messages_conversation_id,priority:1" json:"conversationId"`
+	SenderID       uint   `gorm:"not null;index" json:"senderId"`
+	Body           string `gorm:"type:text;not null" json:"body"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Conversation Conversation `gorm:"foreignKey:ConversationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Sender       User         `gorm:"foreignKey:SenderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
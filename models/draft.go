@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Draft is unpublished work saved by its author. A post draft has TopicID
+// set and may be scheduled with PublishAt; a comment draft has PostID set
+// and is kept at most once per user and post. Drafts are deleted once
+// published.
+type Draft struct {
+	ID      uint  `gorm:"primaryKey" json:"id"`
+	UserID  uint  `gorm:"not null;uniqueIndex:idx_drafts_user_post;index:idx_drafts_user_updated,priority:1" json:"userId"`
+	TopicID *uint `gorm:"index" json:"topicId,omitempty"`
+	PostID  *uint `gorm:"uniqueIndex:idx_drafts_user_post;index" json:"postId,omitempty"`
+
+	Title string   `gorm:"size:120" json:"title"`
+	Body  string   `gorm:"type:text" json:"body"`
+	Tags  []string `gorm:"type:text;serializer:json" json:"tags"`
+
+	// PublishAt is when the scheduler should publish a post draft. If that
+	// fails the schedule is cleared and PublishError says why.
+	PublishAt    *time.Time `gorm:"index" json:"publishAt,omitempty"`
+	PublishError string     `gorm:"size:300" json:"publishError,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `gorm:"index:idx_drafts_user_updated,priority:2" json:"updatedAt"`
+
+	User  User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Topic *Topic `gorm:"foreignKey:TopicID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Post  *Post  `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
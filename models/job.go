@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Job is a unit of background work run by package jobs. Succeeded jobs are
+// deleted; jobs that run out of attempts stay behind in the "dead" state.
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Kind        string     `gorm:"size:64;not null;index" json:"kind"`
+	Payload     string     `gorm:"type:jsonb;not null" json:"payload"`
+	State       string     `gorm:"size:16;not null;default:queued;index:idx_jobs_state_run_at,priority:1" json:"state"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null;default:5" json:"maxAttempts"`
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_state_run_at,priority:2" json:"runAt"`
+	LockedAt    *time.Time `json:"lockedAt,omitempty"`
+	LastError   string     `gorm:"type:text" json:"lastError,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Mention links a user to an @username in a post body (PostID set) or a
+// comment body (CommentID set). Start and End are UTF-16 offsets into the
+// body so clients can slice JavaScript strings directly.
+type Mention struct {
+	ID              uint  `gorm:"primaryKey" json:"id"`
+	PostID          *uint `gorm:"index" json:"postId,omitempty"`
+	CommentID       *uint `gorm:"index" json:"commentId,omitempty"`
+	MentionedUserID uint  `gorm:"not null;index" json:"mentionedUserId"`
+	Start           int   `gorm:"not null" json:"start"`
+	End             int   `gorm:"not null" json:"end"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Post          *Post    `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Comment       *Comment `gorm:"foreignKey:CommentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	MentionedUser User     `gorm:"foreignKey:MentionedUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
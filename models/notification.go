@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index:idx_notifications_user_created,priority:1" json:"userId"`
+	Type      string     `gorm:"size:32;not null" json:"type"`
+	ActorID   uint       `gorm:"not null" json:"actorId"`
+	PostID    *uint      `gorm:"index" json:"postId,omitempty"`
+	CommentID *uint      `gorm:"index" json:"commentId,omitempty"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+
+	CreatedAt time.Time `gorm:"index:idx_notifications_user_created,priority:2" json:"createdAt"`
+
+	User    User     `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Actor   User     `gorm:"foreignKey:ActorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Post    *Post    `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Comment *Comment `gorm:"foreignKey:CommentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Poll is an optional vote attached to a post. ResultsVisibility is
+// "always" or "after_vote"; results are always shown once the poll closes.
+type Poll struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	PostID            uint       `gorm:"not null;uniqueIndex" json:"postId"`
+	Question          string     `gorm:"size:300;not null" json:"question"`
+	MultipleChoice    bool       `gorm:"not null;default:false" json:"multipleChoice"`
+	ResultsVisibility string     `gorm:"size:16;not null;default:always" json:"resultsVisibility"`
+	ClosesAt          *time.Time `json:"closesAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Post    Post         `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Options []PollOption `json:"-"`
+}
+
+type PollOption struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	PollID   uint   `gorm:"not null;index" json:"pollId"`
+	Position int    `gorm:"not null" json:"position"`
+	Text     string `gorm:"size:200;not null" json:"text"`
+
+	Poll Poll `gorm:"foreignKey:PollID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// PollBallot is one user's vote on a poll; the unique index is what makes
+// voting one-per-user. Its choices are the options the user picked.
+type PollBallot struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	PollID uint `gorm:"not null;uniqueIndex:idx_poll_ballots_poll_user" json:"pollId"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_poll_ballots_poll_user;index" json:"userId"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Poll    Poll               `gorm:"foreignKey:PollID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User    User               `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Choices []PollBallotChoice `gorm:"foreignKey:BallotID" json:"-"`
+}
+
+type PollBallotChoice struct {
+	BallotID uint `gorm:"primaryKey" json:"ballotId"`
+	OptionID uint `gorm:"primaryKey;index" json:"optionId"`
+
+	Ballot PollBallot `gorm:"foreignKey:BallotID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Option PollOption `gorm:"foreignKey:OptionID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
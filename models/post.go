@@ -4,14 +4,36 @@ import "time"
 
 type Post struct {
 	ID uint `gorm:"primaryKey" json:"id"`
-	TopicID uint `gorm:"not null;index" json:"topicId"`
-	UserID  uint `gorm:"not null;index" json:"userId"`
+	TopicID uint `gorm:"not null;index;index:idx_posts_topic_created_at,priority:1" json:"topicId"`
+	UserID  uint `gorm:"not null;index;index:idx_posts_user_created,priority:1" json:"userId"`
 	Title string `gorm:"size:120;not null" json:"title"`
 	Body  string `gorm:"type:text;not null" json:"body"`
-	CreatedAt time.Time `json:"createdAt"`
+	RenderedBody string `gorm:"type:text" json:"-"`
+	CreatedAt time.Time `gorm:"index:idx_posts_user_created,priority:2;index:idx_posts_topic_created_at,priority:2" json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	Topic Topic `gorm:"foreignKey:TopicID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	EditedAt *time.Time `gorm:"index" json:"editedAt,omitempty"`
 	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Comments []Comment `json:"-"`
+	Tags     []Tag     `gorm:"many2many:post_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Mentions []Mention `gorm:"foreignKey:PostID" json:"-"`
+	Attachments []Attachment `gorm:"foreignKey:PostID" json:"-"`
+	Poll        *Poll        `gorm:"foreignKey:PostID" json:"-"`
+
+	Pinned     bool       `gorm:"not null;default:false" json:"pinned"`
+	PinnedAt   *time.Time `json:"pinnedAt,omitempty"`
+	Locked     bool       `gorm:"not null;default:false" json:"locked"`
+	LockReason string     `gorm:"size:200" json:"lockReason,omitempty"`
+	LockedAt   *time.Time `json:"lockedAt,omitempty"`
+
+	// AcceptedCommentID is set in Q&A topics once an answer is accepted. It
+	// has no foreign key (posts and comments would reference each other), so
+	// deleting a comment clears it via clearAcceptedAnswer.
+	AcceptedCommentID *uint      `gorm:"index" json:"acceptedCommentId,omitempty"`
+	AcceptedAt        *time.Time `json:"acceptedAt,omitempty"`
+
+	// CommentCount and LastCommentAt are maintained alongside comment
+	// inserts and deletes; db.ReconcileCounters recomputes them.
+	CommentCount  int        `gorm:"not null;default:0" json:"commentCount"`
+	LastCommentAt *time.Time `json:"lastCommentAt,omitempty"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PostRead is a user's read marker on a post: every comment up to and
+// including LastReadCommentID has been seen. It is nil when the post was
+// read before it had any comments.
+type PostRead struct {
+	UserID            uint      `gorm:"primaryKey" json:"userId"`
+	PostID            uint      `gorm:"primaryKey;index" json:"postId"`
+	LastReadCommentID *uint     `json:"lastReadCommentId,omitempty"`
+	ReadAt            time.Time `json:"readAt"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Post Post `gorm:"foreignKey:PostID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
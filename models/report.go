@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Report aggregates every open complaint about one target. Further reports
+// about the same target add a ReportEntry to the open Report instead of
+// opening a new one.
+type Report struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TargetType string `gorm:"size:16;not null;index:idx_reports_target;uniqueIndex:idx_reports_open_target,where:status = 'open'" json:"targetType"`
+	TargetID   uint   `gorm:"not null;index:idx_reports_target;uniqueIndex:idx_reports_open_target,where:status = 'open'" json:"targetId"`
+
+	Status         string    `gorm:"size:16;not null;default:open;index" json:"status"`
+	ReportCount    int       `gorm:"not null;default:0" json:"reportCount"`
+	LastReportedAt time.Time `json:"lastReportedAt"`
+
+	ResolvedByUserID *uint      `gorm:"index" json:"resolvedByUserId,omitempty"`
+	ResolvedAt       *time.Time `json:"resolvedAt,omitempty"`
+	ResolutionNote   string     `gorm:"type:text" json:"resolutionNote"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ResolvedByUser *User         `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	Entries        []ReportEntry `json:"-"`
+}
+
+type ReportEntry struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ReportID   uint   `gorm:"not null;uniqueIndex:idx_report_entries_reporter" json:"reportId"`
+	ReporterID uint   `gorm:"not null;uniqueIndex:idx_report_entries_reporter;index" json:"reporterId"`
+	Reason     string `gorm:"size:32;not null" json:"reason"`
+	Details    string `gorm:"type:text" json:"details"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Report   Report `gorm:"foreignKey:ReportID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Reporter User   `gorm:"foreignKey:ReporterID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// ModerationLog records every decision a moderator makes on a report.
+type ModerationLog struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	ModeratorID uint   `gorm:"not null;index" json:"moderatorId"`
+	ReportID    *uint  `gorm:"index" json:"reportId,omitempty"`
+	Action      string `gorm:"size:32;not null;index" json:"action"`
+	TargetType  string `gorm:"size:16;not null" json:"targetType"`
+	TargetID    uint   `gorm:"not null" json:"targetId"`
+	Note        string `gorm:"type:text" json:"note"`
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+
+	Moderator User    `gorm:"foreignKey:ModeratorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Report    *Report `gorm:"foreignKey:ReportID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+}
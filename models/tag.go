@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:32;not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PostTag is the join table behind Post.Tags, declared explicitly so tag_id
+// gets its own index for tag filters and usage counts.
+type PostTag struct {
+	PostID    uint `gorm:"primaryKey"`
+	TagID     uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TopicFollow subscribes a user to a topic's posts in their home feed.
+type TopicFollow struct {
+	UserID    uint      `gorm:"primaryKey" json:"userId"`
+	TopicID   uint      `gorm:"primaryKey;index" json:"topicId"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	User  User  `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Topic Topic `gorm:"foreignKey:TopicID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
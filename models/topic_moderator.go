@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TopicModerator grants a user moderator rights within a single topic.
+type TopicModerator struct {
+	ID               uint `gorm:"primaryKey" json:"id"`
+	TopicID          uint `gorm:"not null;uniqueIndex:idx_topic_moderators_topic_user" json:"topicId"`
+	UserID           uint `gorm:"not null;uniqueIndex:idx_topic_moderators_topic_user;index" json:"userId"`
+	AssignedByUserID uint `gorm:"not null" json:"assignedByUserId"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	Topic Topic `gorm:"foreignKey:TopicID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User  User  `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -11,6 +11,15 @@ type Topic struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	Posts []Post `json:"-"`
+
+	// QAMode lets post authors (or moderators) accept one comment as the
+	// answer to each post in the topic.
+	QAMode bool `gorm:"not null;default:false" json:"qaMode"`
+
+	// PostCount and LastPostAt are maintained alongside post inserts and
+	// deletes; db.ReconcileCounters recomputes them.
+	PostCount  int        `gorm:"not null;default:0" json:"postCount"`
+	LastPostAt *time.Time `json:"lastPostAt,omitempty"`
 }
 
 	
\ No newline at end of file
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserFollow is an edge in the follow graph: FollowerID follows FolloweeID.
+// The primary key serves "who do I follow"; the followee index serves
+// follower lists and counts.
+type UserFollow struct {
+	FollowerID uint      `gorm:"primaryKey;check:chk_user_follows_not_self,follower_id <> followee_id" json:"followerId"`
+	FolloweeID uint      `gorm:"primaryKey;index:idx_user_follows_followee_created,priority:1" json:"followeeId"`
+	CreatedAt  time.Time `gorm:"index:idx_user_follows_followee_created,priority:2" json:"createdAt"`
+
+	Follower User `gorm:"foreignKey:FollowerID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Followee User `gorm:"foreignKey:FolloweeID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// WebhookSubscription sends the listed forum events to URL. Secret signs
+// each delivery and is never serialized.
+type WebhookSubscription struct {
+	ID              uint     `gorm:"primaryKey" json:"id"`
+	URL             string   `gorm:"size:2048;not null" json:"url"`
+	Secret          string   `gorm:"size:128;not null" json:"-"`
+	Events          []string `gorm:"type:text;serializer:json;not null" json:"events"`
+	Description     string   `gorm:"size:200" json:"description"`
+	Active          bool     `gorm:"not null;default:true" json:"active"`
+	CreatedByUserID uint     `gorm:"not null" json:"createdByUserId"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookDelivery is one event sent (or being sent) to a subscription,
+// with the outcome of its latest attempt. Redeliveries are new rows that
+// point at the delivery they repeat.
+type WebhookDelivery struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint   `gorm:"not null;index:idx_webhook_deliveries_sub_created,priority:1" json:"subscriptionId"`
+	Event          string `gorm:"size:64;not null" json:"event"`
+	Payload        string `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string `gorm:"size:16;not null;default:pending" json:"status"`
+	Attempts       int    `gorm:"not null;default:0" json:"attempts"`
+	RedeliveryOfID *uint  `json:"redeliveryOfId,omitempty"`
+
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	ResponseBody   string     `gorm:"type:text" json:"responseBody,omitempty"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	LastAttemptAt  *time.Time `json:"lastAttemptAt,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+
+	CreatedAt time.Time `gorm:"index:idx_webhook_deliveries_sub_created,priority:2" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
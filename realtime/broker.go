@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Event is a single message pushed to subscribers of a channel. Data is kept
+// as raw JSON so events can cross process boundaries unchanged.
+type Event struct {
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Broker fans events out to subscribers. Implementations must be safe for
+// concurrent use.
+type Broker interface {
+	Publish(ctx context.Context, ev Event) error
+	Subscribe(channel string) (<-chan Event, func())
+}
+
+func PostChannel(postID uint) string   { return fmt.Sprintf("post:%d", postID) }
+func TopicChannel(topicID uint) string { return fmt.Sprintf("topic:%d", topicID) }
+func UserChannel(userID uint) string   { return fmt.Sprintf("user:%d", userID) }
+
+const subscriberBuffer = 32
+
+// MemoryBroker delivers events to subscribers in the same process. Slow
+// subscribers whose buffer is full miss events rather than block publishers.
+type MemoryBroker struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: map[string]map[chan Event]struct{}{}}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, ev Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[ev.Channel] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(channel string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[channel] == nil {
+		b.subs[channel] = map[chan Event]struct{}{}
+	}
+	b.subs[channel][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[channel], ch)
+			if len(b.subs[channel]) == 0 {
+				delete(b.subs, channel)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
@@ -0,0 +1,89 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const pgChannel = "forum_events"
+
+// Postgres caps NOTIFY payloads at 8000 bytes.
+const maxNotifyPayload = 7900
+
+// PGBroker relays events through Postgres LISTEN/NOTIFY so that subscribers
+// connected to any instance receive events published by any other instance.
+// Events are only delivered locally once they come back from Postgres, which
+// keeps ordering identical on every instance.
+type PGBroker struct {
+	db    *sql.DB
+	dsn   string
+	local *MemoryBroker
+}
+
+func NewPGBroker(db *sql.DB, dsn string) *PGBroker {
+	return &PGBroker{db: db, dsn: dsn, local: NewMemoryBroker()}
+}
+
+func (b *PGBroker) Publish(ctx context.Context, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return errors.New("event payload too large for NOTIFY")
+	}
+	_, err = b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", pgChannel, string(payload))
+	return err
+}
+
+func (b *PGBroker) Subscribe(channel string) (<-chan Event, func()) {
+	return b.local.Subscribe(channel)
+}
+
+// Listen holds a dedicated connection open and forwards notifications to
+// local subscribers until ctx is cancelled, reconnecting on failure.
+func (b *PGBroker) Listen(ctx context.Context) {
+	for {
+		if err := b.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("realtime: listen error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (b *PGBroker) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgChannel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var ev Event
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			log.Printf("realtime: bad notification payload: %v", err)
+			continue
+		}
+		_ = b.local.Publish(ctx, ev)
+	}
+}
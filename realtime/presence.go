@@ -0,0 +1,226 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PresenceClient is one connection taking part in a presence room. Send must
+// not block; it reports false when the message could not be queued.
+type PresenceClient interface {
+	Send(msg []byte) bool
+	Close()
+}
+
+type PresenceUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type PresenceState struct {
+	PostID  uint           `json:"postId"`
+	Viewers []PresenceUser `json:"viewers"`
+	Typing  []PresenceUser `json:"typing"`
+}
+
+type presenceMember struct {
+	user        PresenceUser
+	lastSeen    time.Time
+	typingUntil time.Time
+}
+
+// PresenceHub tracks who is viewing and typing on each post and pushes the
+// current state to every client in the room whenever it changes. Members that
+// miss heartbeats for longer than TTL are dropped by Sweep.
+type PresenceHub struct {
+	TTL       time.Duration
+	TypingTTL time.Duration
+	Now       func() time.Time
+
+	mu    sync.Mutex
+	rooms map[uint]map[PresenceClient]*presenceMember
+}
+
+func NewPresenceHub(ttl, typingTTL time.Duration) *PresenceHub {
+	return &PresenceHub{
+		TTL:       ttl,
+		TypingTTL: typingTTL,
+		Now:       time.Now,
+		rooms:     map[uint]map[PresenceClient]*presenceMember{},
+	}
+}
+
+func (h *PresenceHub) Join(postID uint, c PresenceClient, u PresenceUser) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rooms[postID] == nil {
+		h.rooms[postID] = map[PresenceClient]*presenceMember{}
+	}
+	h.rooms[postID][c] = &presenceMember{user: u, lastSeen: h.Now()}
+	h.broadcastLocked(postID)
+}
+
+func (h *PresenceHub) Leave(postID uint, c PresenceClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.removeLocked(postID, c) {
+		h.broadcastLocked(postID)
+	}
+}
+
+func (h *PresenceHub) Heartbeat(postID uint, c PresenceClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if m := h.rooms[postID][c]; m != nil {
+		m.lastSeen = h.Now()
+	}
+}
+
+// SetTyping marks the client as typing for TypingTTL, or clears the flag.
+// Clients are expected to resend typing=true while the user keeps typing.
+func (h *PresenceHub) SetTyping(postID uint, c PresenceClient, typing bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := h.rooms[postID][c]
+	if m == nil {
+		return
+	}
+
+	now := h.Now()
+	wasTyping := m.typingUntil.After(now)
+	m.lastSeen = now
+	if typing {
+		m.typingUntil = now.Add(h.TypingTTL)
+	} else {
+		m.typingUntil = time.Time{}
+	}
+
+	if wasTyping != typing {
+		h.broadcastLocked(postID)
+	}
+}
+
+func (h *PresenceHub) Snapshot(postID uint) PresenceState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.snapshotLocked(postID)
+}
+
+// Sweep drops members whose heartbeat has expired, clears typing flags that
+// have timed out and notifies the affected rooms.
+func (h *PresenceHub) Sweep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.Now()
+	for postID, room := range h.rooms {
+		changed := false
+		for c, m := range room {
+			if now.Sub(m.lastSeen) > h.TTL {
+				delete(room, c)
+				c.Close()
+				changed = true
+				continue
+			}
+			if !m.typingUntil.IsZero() && !m.typingUntil.After(now) {
+				m.typingUntil = time.Time{}
+				changed = true
+			}
+		}
+		if len(room) == 0 {
+			delete(h.rooms, postID)
+			continue
+		}
+		if changed {
+			h.broadcastLocked(postID)
+		}
+	}
+}
+
+// Run calls Sweep on an interval until ctx is cancelled.
+func (h *PresenceHub) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Sweep()
+		}
+	}
+}
+
+func (h *PresenceHub) removeLocked(postID uint, c PresenceClient) bool {
+	room := h.rooms[postID]
+	if _, ok := room[c]; !ok {
+		return false
+	}
+	delete(room, c)
+	if len(room) == 0 {
+		delete(h.rooms, postID)
+	}
+	return true
+}
+
+// snapshotLocked lists each user once even if they have several tabs open.
+func (h *PresenceHub) snapshotLocked(postID uint) PresenceState {
+	now := h.Now()
+	viewers := map[uint]PresenceUser{}
+	typing := map[uint]PresenceUser{}
+	for _, m := range h.rooms[postID] {
+		viewers[m.user.ID] = m.user
+		if m.typingUntil.After(now) {
+			typing[m.user.ID] = m.user
+		}
+	}
+
+	return PresenceState{
+		PostID:  postID,
+		Viewers: sortedUsers(viewers),
+		Typing:  sortedUsers(typing),
+	}
+}
+
+func (h *PresenceHub) broadcastLocked(postID uint) {
+	room := h.rooms[postID]
+	if len(room) == 0 {
+		return
+	}
+
+	msg, err := json.Marshal(struct {
+		Type string `json:"type"`
+		PresenceState
+	}{Type: "presence", PresenceState: h.snapshotLocked(postID)})
+	if err != nil {
+		return
+	}
+
+	for c := range room {
+		if !c.Send(msg) {
+			delete(room, c)
+			c.Close()
+		}
+	}
+	if len(room) == 0 {
+		delete(h.rooms, postID)
+	}
+}
+
+func sortedUsers(m map[uint]PresenceUser) []PresenceUser {
+	out := make([]PresenceUser, 0, len(m))
+	for _, u := range m {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,197 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fakeClient records what the hub sends it. When full is set it refuses
+// messages, the way a disconnected websocket's send queue does.
+type fakeClient struct {
+	msgs   []PresenceState
+	full   bool
+	closed bool
+}
+
+func (c *fakeClient) Send(msg []byte) bool {
+	if c.full {
+		return false
+	}
+	var st PresenceState
+	if err := json.Unmarshal(msg, &st); err != nil {
+		panic(err)
+	}
+	c.msgs = append(c.msgs, st)
+	return true
+}
+
+func (c *fakeClient) Close() { c.closed = true }
+
+func (c *fakeClient) last(t *testing.T) PresenceState {
+	t.Helper()
+	if len(c.msgs) == 0 {
+		t.Fatal("client received no presence messages")
+	}
+	return c.msgs[len(c.msgs)-1]
+}
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestHub() (*PresenceHub, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	h := NewPresenceHub(30*time.Second, 5*time.Second)
+	h.Now = clock.Now
+	return h, clock
+}
+
+func userIDs(users []PresenceUser) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, what string, got []PresenceUser, want ...uint) {
+	t.Helper()
+	ids := userIDs(got)
+	if len(ids) != len(want) {
+		t.Fatalf("%s = %v, want %v", what, ids, want)
+	}
+	for i := range ids {
+		if ids[i] != want[i] {
+			t.Fatalf("%s = %v, want %v", what, ids, want)
+		}
+	}
+}
+
+func TestJoinBroadcastsViewers(t *testing.T) {
+	h, _ := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	st := alice.last(t)
+	if st.PostID != 1 {
+		t.Fatalf("postId = %d, want 1", st.PostID)
+	}
+	assertIDs(t, "viewers", st.Viewers, 1)
+
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+	assertIDs(t, "alice's viewers", alice.last(t).Viewers, 1, 2)
+	assertIDs(t, "bob's viewers", bob.last(t).Viewers, 1, 2)
+
+	other := &fakeClient{}
+	h.Join(2, other, PresenceUser{ID: 3, Username: "carol"})
+	if len(alice.msgs) != 2 {
+		t.Fatalf("joining another post notified alice: %d messages", len(alice.msgs))
+	}
+}
+
+func TestJoinListsUserOnceAcrossTabs(t *testing.T) {
+	h, _ := newTestHub()
+	tab1, tab2 := &fakeClient{}, &fakeClient{}
+
+	h.Join(1, tab1, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, tab2, PresenceUser{ID: 1, Username: "alice"})
+	assertIDs(t, "viewers", tab1.last(t).Viewers, 1)
+}
+
+func TestSweepDropsViewersWithoutHeartbeat(t *testing.T) {
+	h, clock := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+
+	clock.Advance(20 * time.Second)
+	h.Heartbeat(1, alice)
+	clock.Advance(20 * time.Second)
+	h.Sweep()
+
+	if !bob.closed {
+		t.Fatal("stale client was not closed")
+	}
+	if alice.closed {
+		t.Fatal("client with a fresh heartbeat was closed")
+	}
+	assertIDs(t, "viewers", alice.last(t).Viewers, 1)
+	assertIDs(t, "snapshot viewers", h.Snapshot(1).Viewers, 1)
+
+	clock.Advance(31 * time.Second)
+	h.Sweep()
+	if !alice.closed {
+		t.Fatal("client was not closed once its heartbeat expired")
+	}
+	assertIDs(t, "snapshot viewers", h.Snapshot(1).Viewers)
+}
+
+func TestTypingExpiresAfterTypingTTL(t *testing.T) {
+	h, clock := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+
+	h.SetTyping(1, alice, true)
+	assertIDs(t, "typing", bob.last(t).Typing, 1)
+
+	clock.Advance(4 * time.Second)
+	sent := len(bob.msgs)
+	h.Sweep()
+	if len(bob.msgs) != sent {
+		t.Fatal("sweep broadcast before the typing flag expired")
+	}
+	assertIDs(t, "typing", h.Snapshot(1).Typing, 1)
+
+	clock.Advance(2 * time.Second)
+	h.Sweep()
+	assertIDs(t, "typing", bob.last(t).Typing)
+	assertIDs(t, "viewers", bob.last(t).Viewers, 1, 2)
+}
+
+func TestSetTypingFalseClearsImmediately(t *testing.T) {
+	h, _ := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+
+	h.SetTyping(1, alice, true)
+	h.SetTyping(1, alice, false)
+	assertIDs(t, "typing", bob.last(t).Typing)
+}
+
+func TestLeaveRemovesViewer(t *testing.T) {
+	h, _ := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+
+	h.Leave(1, bob)
+	assertIDs(t, "viewers", alice.last(t).Viewers, 1)
+
+	sent := len(alice.msgs)
+	h.Leave(1, bob)
+	if len(alice.msgs) != sent {
+		t.Fatal("leaving twice broadcast again")
+	}
+}
+
+func TestDisconnectedClientIsRemoved(t *testing.T) {
+	h, _ := newTestHub()
+	alice, bob := &fakeClient{}, &fakeClient{}
+	h.Join(1, alice, PresenceUser{ID: 1, Username: "alice"})
+	h.Join(1, bob, PresenceUser{ID: 2, Username: "bob"})
+
+	bob.full = true
+	h.SetTyping(1, alice, true)
+
+	if !bob.closed {
+		t.Fatal("client that could not be sent to was not closed")
+	}
+	assertIDs(t, "viewers", h.Snapshot(1).Viewers, 1)
+
+	h.SetTyping(1, alice, false)
+	assertIDs(t, "viewers", alice.last(t).Viewers, 1)
+}
@@ -0,0 +1,19 @@
+// Package storage holds uploaded attachment bytes behind a small interface so
+// the local-disk backend can be swapped for an object store later.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores opaque objects under slash-separated keys. Keys are chosen
+// by the caller and never derived from user input.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxImagePixels bounds decoded image size so a small, highly compressed
+// upload can't exhaust memory when thumbnailed.
+const MaxImagePixels = 40_000_000
+
+var ErrImageTooLarge = errors.New("storage: image dimensions too large")
+
+// ImageSize returns the dimensions from the image header without decoding
+// the pixel data.
+func ImageSize(data []byte) (width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Thumbnail decodes data and returns a JPEG scaled to fit within size×size,
+// preserving aspect ratio. Images already small enough are re-encoded as-is.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	w, h, err := ImageSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if w*h > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
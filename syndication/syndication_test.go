@@ -0,0 +1,171 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	published = time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("SGT", 8*3600))
+	edited    = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+)
+
+func testFeed() Feed {
+	return Feed{
+		ID:       "tag:forum.example,2026-01-01:topic:1",
+		Title:    "General",
+		Subtitle: "Anything goes",
+		Link:     "https://forum.example/topics/1",
+		SelfLink: "https://api.example/topics/1/feed.atom",
+		Updated:  edited,
+		Entries: []Entry{{
+			ID:         TagURI("forum.example", published, "post:7"),
+			Title:      "Fish & chips",
+			Link:       "https://forum.example/posts/7",
+			Author:     "alice",
+			HTML:       `<p>a &amp; <em>b</em></p>`,
+			Categories: []string{"food", "uk"},
+			Published:  published,
+			Updated:    edited,
+		}},
+	}
+}
+
+func TestTagURI(t *testing.T) {
+	// The date is taken in UTC, so a post created just after midnight in
+	// another zone keeps the same ID wherever the server runs.
+	got := TagURI("forum.example", published, "post:7")
+	if want := "tag:forum.example,2026-03-01:post:7"; got != want {
+		t.Fatalf("TagURI = %q, want %q", got, want)
+	}
+	late := time.Date(2026, 3, 2, 1, 0, 0, 0, time.FixedZone("SGT", 8*3600))
+	if got := TagURI("forum.example", late, "post:8"); got != "tag:forum.example,2026-03-01:post:8" {
+		t.Fatalf("TagURI = %q, want the UTC date", got)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	out, err := testFeed().Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), xml.Header) {
+		t.Fatal("Atom output has no XML declaration")
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Author    string `xml:"author>name"`
+			Category  []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Content struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("Atom output does not parse: %v\n%s", err, out)
+	}
+
+	if doc.ID != "tag:forum.example,2026-01-01:topic:1" || doc.Updated != "2026-03-02T10:00:00Z" {
+		t.Errorf("feed id/updated = %q/%q", doc.ID, doc.Updated)
+	}
+	if len(doc.Links) != 2 || doc.Links[0].Rel != "alternate" || doc.Links[1].Rel != "self" ||
+		doc.Links[1].Href != "https://api.example/topics/1/feed.atom" {
+		t.Errorf("links = %+v", doc.Links)
+	}
+	if len(doc.Entries) != 1 {
+		t.Fatalf("%d entries, want 1", len(doc.Entries))
+	}
+	e := doc.Entries[0]
+	if e.ID != "tag:forum.example,2026-03-01:post:7" || e.Title != "Fish & chips" || e.Author != "alice" {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Published != "2026-03-01T01:30:00Z" || e.Updated != "2026-03-02T10:00:00Z" {
+		t.Errorf("entry published/updated = %q/%q, want UTC times", e.Published, e.Updated)
+	}
+	if len(e.Category) != 2 || e.Category[0].Term != "food" || e.Category[1].Term != "uk" {
+		t.Errorf("categories = %+v", e.Category)
+	}
+	if e.Content.Type != "html" || e.Content.Body != `<p>a &amp; <em>b</em></p>` {
+		t.Errorf("content = %+v, want the HTML escaped as text", e.Content)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := testFeed().RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title string `xml:"title"`
+				Link  string `xml:"link"`
+				GUID  struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Creator     string   `xml:"creator"`
+				Categories  []string `xml:"category"`
+				Description string   `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("RSS output does not parse: %v\n%s", err, out)
+	}
+
+	if doc.Version != "2.0" || doc.Channel.Description != "Anything goes" {
+		t.Errorf("version/description = %q/%q", doc.Version, doc.Channel.Description)
+	}
+	if doc.Channel.LastBuildDate != "Mon, 02 Mar 2026 10:00:00 +0000" {
+		t.Errorf("lastBuildDate = %q", doc.Channel.LastBuildDate)
+	}
+	if len(doc.Channel.Items) != 1 {
+		t.Fatalf("%d items, want 1", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	if item.GUID.IsPermaLink != "false" || item.GUID.Value != "tag:forum.example,2026-03-01:post:7" {
+		t.Errorf("guid = %+v, want the entry ID as a non-permalink", item.GUID)
+	}
+	if item.PubDate != "Sun, 01 Mar 2026 01:30:00 +0000" || item.Creator != "alice" {
+		t.Errorf("pubDate/creator = %q/%q", item.PubDate, item.Creator)
+	}
+	if len(item.Categories) != 2 || item.Description != `<p>a &amp; <em>b</em></p>` {
+		t.Errorf("categories/description = %v/%q", item.Categories, item.Description)
+	}
+}
+
+func TestRSSDescriptionFallsBackToTitle(t *testing.T) {
+	f := testFeed()
+	f.Subtitle = ""
+	out, err := f.RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "<description>General</description>") {
+		t.Fatalf("channel description is not the title:\n%s", out)
+	}
+}
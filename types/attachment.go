@@ -0,0 +1,47 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
+)
+
+type AttachmentResponse struct {
+	ID           uint      `json:"id"`
+	FileName     string    `json:"fileName"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ToAttachmentResponse links to the attachment with URLs signed by signer.
+func ToAttachmentResponse(a models.Attachment, signer *storage.URLSigner) AttachmentResponse {
+	out := AttachmentResponse{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Width:       a.Width,
+		Height:      a.Height,
+		URL:         signer.Sign(fmt.Sprintf("/attachments/%d", a.ID)),
+		CreatedAt:   a.CreatedAt,
+	}
+	if a.ThumbnailKey != "" {
+		out.ThumbnailURL = signer.Sign(fmt.Sprintf("/attachments/%d/thumbnail", a.ID))
+	}
+	return out
+}
+
+func ToAttachmentResponses(as []models.Attachment, signer *storage.URLSigner) []AttachmentResponse {
+	out := make([]AttachmentResponse, 0, len(as))
+	for _, a := range as {
+		out = append(out, ToAttachmentResponse(a, signer))
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type AuditLogResponse struct {
+	ID         uint            `json:"id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   uint            `json:"targetId"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"requestId"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Actor      UserPublic      `json:"actor"`
+}
+
+func ToAuditLogResponse(l models.AuditLog, actor models.User) AuditLogResponse {
+	return AuditLogResponse{
+		ID:         l.ID,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		Before:     l.Before,
+		After:      l.After,
+		RequestID:  l.RequestID,
+		CreatedAt:  l.CreatedAt,
+		Actor:      ToUserPublic(actor),
+	}
+}
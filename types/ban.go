@@ -0,0 +1,31 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type BanResponse struct {
+	ID        uint       `json:"id"`
+	TopicID   *uint      `json:"topicId,omitempty"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	User      UserPublic `json:"user"`
+	IssuedBy  UserPublic `json:"issuedBy"`
+}
+
+func ToBanResponse(b models.Ban) BanResponse {
+	return BanResponse{
+		ID:        b.ID,
+		TopicID:   b.TopicID,
+		Reason:    b.Reason,
+		ExpiresAt: b.ExpiresAt,
+		RevokedAt: b.RevokedAt,
+		CreatedAt: b.CreatedAt,
+		User:      ToUserPublic(b.User),
+		IssuedBy:  ToUserPublic(b.IssuedBy),
+	}
+}
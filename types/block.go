@@ -0,0 +1,9 @@
+package types
+
+import "time"
+
+// UserRelationResponse is one entry in a block or mute list.
+type UserRelationResponse struct {
+	User      UserPublic `json:"user"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
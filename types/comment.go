@@ -3,31 +3,54 @@ package types
 import (
 	"time"
 
+	"CVWO-Backend/markdown"
 	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
 )
 
 type CommentResponse struct {
-	ID     uint `json:"id"`
-	PostID uint `json:"postId"`
-	UserID uint `json:"userId"`
-	Body   string `json:"body"`
+	ID       uint   `json:"id"`
+	PostID   uint   `json:"postId"`
+	UserID   uint   `json:"userId"`
+	Body     string `json:"body"`
+	BodyHTML string `json:"bodyHtml"`
 
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	EditedAt  *time.Time `json:"editedAt,omitempty"`
 
-	Author UserPublic `json:"author"`
+	Author      UserPublic           `json:"author"`
+	Mentions    []MentionSpan        `json:"mentions"`
+	Attachments []AttachmentResponse `json:"attachments"`
+
+	// Muted marks comments by users the viewer muted or blocked
+	// (?muted=collapse).
+	Muted bool `json:"muted,omitempty"`
+	// Accepted marks the accepted answer of a Q&A post.
+	Accepted bool `json:"accepted,omitempty"`
 }
 
-func ToCommentResponse(c models.Comment) CommentResponse {
+func ToCommentResponse(c models.Comment, signer *storage.URLSigner) CommentResponse {
 	return CommentResponse{
-		ID:        c.ID,
-		PostID:    c.PostID,
-		UserID:    c.UserID,
-		Body:      c.Body,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-		EditedAt:  c.EditedAt,
-		Author:    ToUserPublic(c.User),
+		ID:          c.ID,
+		PostID:      c.PostID,
+		UserID:      c.UserID,
+		Body:        c.Body,
+		BodyHTML:    RenderedBody(c.RenderedBody, c.Body),
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		EditedAt:    c.EditedAt,
+		Author:      ToUserPublic(c.User),
+		Mentions:    ToMentionSpans(c.Mentions),
+		Attachments: ToAttachmentResponses(c.Attachments, signer),
+	}
+}
+
+// RenderedBody prefers the HTML cached at write time and renders on the fly
+// for rows written before rendering was introduced.
+func RenderedBody(cached, body string) string {
+	if cached != "" {
+		return cached
 	}
+	return markdown.Render(body)
 }
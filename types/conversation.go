@@ -0,0 +1,68 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type ParticipantResponse struct {
+	User              UserPublic `json:"user"`
+	LastReadMessageID *uint      `json:"lastReadMessageId,omitempty"`
+	LastReadAt        *time.Time `json:"lastReadAt,omitempty"`
+}
+
+type MessageResponse struct {
+	ID             uint       `json:"id"`
+	ConversationID uint       `json:"conversationId"`
+	Body           string     `json:"body"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	Sender         UserPublic `json:"sender"`
+}
+
+type ConversationResponse struct {
+	ID            uint                  `json:"id"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	LastMessageAt *time.Time            `json:"lastMessageAt,omitempty"`
+	Participants  []ParticipantResponse `json:"participants"`
+	LastMessage   *MessageResponse      `json:"lastMessage,omitempty"`
+	UnreadCount   int64                 `json:"unreadCount"`
+}
+
+func ToMessageResponse(m models.Message) MessageResponse {
+	return MessageResponse{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		Body:           m.Body,
+		CreatedAt:      m.CreatedAt,
+		Sender:         ToUserPublic(m.Sender),
+	}
+}
+
+func ToParticipantResponse(p models.ConversationParticipant) ParticipantResponse {
+	return ParticipantResponse{
+		User:              ToUserPublic(p.User),
+		LastReadMessageID: p.LastReadMessageID,
+		LastReadAt:        p.LastReadAt,
+	}
+}
+
+func ToConversationResponse(c models.Conversation) ConversationResponse {
+	participants := make([]ParticipantResponse, 0, len(c.Participants))
+	for _, p := range c.Participants {
+		participants = append(participants, ToParticipantResponse(p))
+	}
+	return ConversationResponse{
+		ID:            c.ID,
+		CreatedAt:     c.CreatedAt,
+		LastMessageAt: c.LastMessageAt,
+		Participants:  participants,
+	}
+}
+
+// MessagePageResponse is a page of messages, newest first. NextBefore is the
+// before value for the next (older) page and is omitted on the last one.
+type MessagePageResponse struct {
+	Items      []MessageResponse `json:"items"`
+	NextBefore uint              `json:"nextBefore,omitempty"`
+}
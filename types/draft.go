@@ -0,0 +1,47 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+// DraftResponse is a saved draft. Kind is "post" (TopicID set) or "comment"
+// (PostID set).
+type DraftResponse struct {
+	ID           uint       `json:"id"`
+	Kind         string     `json:"kind"`
+	TopicID      *uint      `json:"topicId,omitempty"`
+	PostID       *uint      `json:"postId,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Body         string     `json:"body"`
+	Tags         []string   `json:"tags"`
+	PublishAt    *time.Time `json:"publishAt,omitempty"`
+	PublishError string     `json:"publishError,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func ToDraftResponse(d models.Draft) DraftResponse {
+	kind := "post"
+	if d.PostID != nil {
+		kind = "comment"
+	}
+	tags := d.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return DraftResponse{
+		ID:           d.ID,
+		Kind:         kind,
+		TopicID:      d.TopicID,
+		PostID:       d.PostID,
+		Title:        d.Title,
+		Body:         d.Body,
+		Tags:         tags,
+		PublishAt:    d.PublishAt,
+		PublishError: d.PublishError,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+}
@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+// FeedResponse is a cursor-paged list of posts. NextCursor is empty on the
+// last page.
+type FeedResponse struct {
+	Items      []PostResponse `json:"items"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	// Fallback is true when the user follows no topics and the feed was
+	// built from the default topics instead.
+	Fallback bool `json:"fallback"`
+}
+
+// ActivityItem is a post or a comment in an activity feed; exactly one of
+// Post and Comment is set, matching Type.
+type ActivityItem struct {
+	Type      string           `json:"type"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Post      *PostResponse    `json:"post,omitempty"`
+	Comment   *CommentResponse `json:"comment,omitempty"`
+	PostTitle string           `json:"postTitle,omitempty"`
+}
+
+type ActivityFeedResponse struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type JobResponse struct {
+	ID          uint            `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	State       string          `json:"state"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+func ToJobResponse(j models.Job) JobResponse {
+	return JobResponse{
+		ID:          j.ID,
+		Kind:        j.Kind,
+		Payload:     json.RawMessage(j.Payload),
+		State:       j.State,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		LastError:   j.LastError,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+import "CVWO-Backend/models"
+
+type MentionSpan struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+func ToMentionSpans(ms []models.Mention) []MentionSpan {
+	out := make([]MentionSpan, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, MentionSpan{
+			UserID:   m.MentionedUserID,
+			Username: m.MentionedUser.Username,
+			Start:    m.Start,
+			End:      m.End,
+		})
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type NotificationResponse struct {
+	ID        uint       `json:"id"`
+	Type      string     `json:"type"`
+	PostID    *uint      `json:"postId,omitempty"`
+	CommentID *uint      `json:"commentId,omitempty"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Actor     UserPublic `json:"actor"`
+}
+
+func ToNotificationResponse(n models.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		PostID:    n.PostID,
+		CommentID: n.CommentID,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+		Actor:     ToUserPublic(n.Actor),
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+// PollOptionResponse carries Votes only when results are visible to the
+// viewer.
+type PollOptionResponse struct {
+	ID    uint   `json:"id"`
+	Text  string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type PollResponse struct {
+	ID                uint                 `json:"id"`
+	Question          string               `json:"question"`
+	MultipleChoice    bool                 `json:"multipleChoice"`
+	ResultsVisibility string               `json:"resultsVisibility"`
+	ClosesAt          *time.Time           `json:"closesAt,omitempty"`
+	Closed            bool                 `json:"closed"`
+	Options           []PollOptionResponse `json:"options"`
+
+	ResultsVisible bool   `json:"resultsVisible"`
+	TotalVoters    *int64 `json:"totalVoters,omitempty"`
+	// MyVotes is only set when the request identifies a viewer; it is
+	// empty until they vote.
+	MyVotes []uint `json:"myVotes,omitempty"`
+	Voted   *bool  `json:"voted,omitempty"`
+}
+
+// ToPollResponse maps the poll's structure. Tallies and the viewer's votes
+// are filled in separately because they depend on who is asking.
+func ToPollResponse(p models.Poll, now time.Time) PollResponse {
+	options := make([]PollOptionResponse, 0, len(p.Options))
+	for _, o := range p.Options {
+		options = append(options, PollOptionResponse{ID: o.ID, Text: o.Text})
+	}
+	return PollResponse{
+		ID:                p.ID,
+		Question:          p.Question,
+		MultipleChoice:    p.MultipleChoice,
+		ResultsVisibility: p.ResultsVisibility,
+		ClosesAt:          p.ClosesAt,
+		Closed:            p.ClosesAt != nil && !now.Before(*p.ClosesAt),
+		Options:           options,
+	}
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"CVWO-Backend/models"
+	"CVWO-Backend/storage"
 	"time"
 )
 
@@ -11,22 +12,71 @@ type PostResponse struct {
 	UserID    uint       `json:"userId"`
 	Title     string     `json:"title"`
 	Body      string     `json:"body"`
+	BodyHTML  string     `json:"bodyHtml"`
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	EditedAt  *time.Time `json:"editedAt,omitempty"`
 	Author    UserPublic `json:"author"`
+
+	Tags        []string             `json:"tags"`
+	Mentions    []MentionSpan        `json:"mentions"`
+	Attachments []AttachmentResponse `json:"attachments"`
+	Poll        *PollResponse        `json:"poll,omitempty"`
+	Pinned      bool                 `json:"pinned"`
+	Locked      bool                 `json:"locked"`
+	LockReason  string               `json:"lockReason,omitempty"`
+
+	AcceptedCommentID *uint `json:"acceptedCommentId,omitempty"`
+	Solved            bool  `json:"solved"`
+
+	CommentCount  int        `json:"commentCount"`
+	LastCommentAt *time.Time `json:"lastCommentAt,omitempty"`
+
+	// Bookmarked is only set when the request identifies a viewer. Muted
+	// marks posts by users the viewer muted or blocked (?muted=collapse).
+	Bookmarked *bool `json:"bookmarked,omitempty"`
+	Muted      bool  `json:"muted,omitempty"`
+
+	// UnreadCount is also viewer-only: comments by others after the
+	// viewer's read marker, or all of them if they never opened the post.
+	UnreadCount          *int64 `json:"unreadCount,omitempty"`
+	FirstUnreadCommentID *uint  `json:"firstUnreadCommentId,omitempty"`
 }
 
-func ToPostResponse(p models.Post) PostResponse {
+func ToPostResponse(p models.Post, signer *storage.URLSigner) PostResponse {
+	tags := make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		tags = append(tags, t.Name)
+	}
+	var poll *PollResponse
+	if p.Poll != nil {
+		pr := ToPollResponse(*p.Poll, time.Now())
+		poll = &pr
+	}
 	return PostResponse{
 		ID:        p.ID,
 		TopicID:   p.TopicID,
 		UserID:    p.UserID,
 		Title:     p.Title,
 		Body:      p.Body,
+		BodyHTML:  RenderedBody(p.RenderedBody, p.Body),
 		CreatedAt: p.CreatedAt,
 		UpdatedAt: p.UpdatedAt,
 		EditedAt:  p.EditedAt,
 		Author:    ToUserPublic(p.User),
+
+		Tags:        tags,
+		Mentions:    ToMentionSpans(p.Mentions),
+		Attachments: ToAttachmentResponses(p.Attachments, signer),
+		Poll:        poll,
+		Pinned:      p.Pinned,
+		Locked:      p.Locked,
+		LockReason:  p.LockReason,
+
+		AcceptedCommentID: p.AcceptedCommentID,
+		Solved:            p.AcceptedCommentID != nil,
+
+		CommentCount:  p.CommentCount,
+		LastCommentAt: p.LastCommentAt,
 	}
 }
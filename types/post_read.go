@@ -0,0 +1,21 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type PostReadResponse struct {
+	PostID            uint      `json:"postId"`
+	LastReadCommentID *uint     `json:"lastReadCommentId,omitempty"`
+	ReadAt            time.Time `json:"readAt"`
+}
+
+func ToPostReadResponse(pr models.PostRead) PostReadResponse {
+	return PostReadResponse{
+		PostID:            pr.PostID,
+		LastReadCommentID: pr.LastReadCommentID,
+		ReadAt:            pr.ReadAt,
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type ReportEntryResponse struct {
+	ID        uint       `json:"id"`
+	Reason    string     `json:"reason"`
+	Details   string     `json:"details"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Reporter  UserPublic `json:"reporter"`
+}
+
+type ReportResponse struct {
+	ID               uint                  `json:"id"`
+	TargetType       string                `json:"targetType"`
+	TargetID         uint                  `json:"targetId"`
+	TargetPreview    string                `json:"targetPreview"`
+	Status           string                `json:"status"`
+	ReportCount      int                   `json:"reportCount"`
+	Reasons          map[string]int        `json:"reasons"`
+	LastReportedAt   time.Time             `json:"lastReportedAt"`
+	ResolvedByUserID *uint                 `json:"resolvedByUserId,omitempty"`
+	ResolvedAt       *time.Time            `json:"resolvedAt,omitempty"`
+	ResolutionNote   string                `json:"resolutionNote,omitempty"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	Entries          []ReportEntryResponse `json:"entries,omitempty"`
+}
+
+type ModerationLogResponse struct {
+	ID         uint       `json:"id"`
+	ReportID   *uint      `json:"reportId,omitempty"`
+	Action     string     `json:"action"`
+	TargetType string     `json:"targetType"`
+	TargetID   uint       `json:"targetId"`
+	Note       string     `json:"note"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Moderator  UserPublic `json:"moderator"`
+}
+
+type PageResponse[T any] struct {
+	Items    []T   `json:"items"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"pageSize"`
+	Total    int64 `json:"total"`
+}
+
+func ToReportResponse(r models.Report, preview string) ReportResponse {
+	reasons := map[string]int{}
+	entries := make([]ReportEntryResponse, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		reasons[e.Reason]++
+		entries = append(entries, ReportEntryResponse{
+			ID:        e.ID,
+			Reason:    e.Reason,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt,
+			Reporter:  ToUserPublic(e.Reporter),
+		})
+	}
+	return ReportResponse{
+		ID:               r.ID,
+		TargetType:       r.TargetType,
+		TargetID:         r.TargetID,
+		TargetPreview:    preview,
+		Status:           r.Status,
+		ReportCount:      r.ReportCount,
+		Reasons:          reasons,
+		LastReportedAt:   r.LastReportedAt,
+		ResolvedByUserID: r.ResolvedByUserID,
+		ResolvedAt:       r.ResolvedAt,
+		ResolutionNote:   r.ResolutionNote,
+		CreatedAt:        r.CreatedAt,
+		Entries:          entries,
+	}
+}
+
+func ToModerationLogResponse(l models.ModerationLog) ModerationLogResponse {
+	return ModerationLogResponse{
+		ID:         l.ID,
+		ReportID:   l.ReportID,
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		Note:       l.Note,
+		CreatedAt:  l.CreatedAt,
+		Moderator:  ToUserPublic(l.Moderator),
+	}
+}
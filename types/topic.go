@@ -13,6 +13,9 @@ type TopicResponse struct {
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
 	Author          *UserPublic `json:"author,omitempty"`
+	QAMode          bool       `json:"qaMode"`
+	PostCount       int        `json:"postCount"`
+	LastPostAt      *time.Time `json:"lastPostAt,omitempty"`
 }
 
 func ToTopicResponse(t models.Topic) TopicResponse {
@@ -29,5 +32,8 @@ func ToTopicResponse(t models.Topic) TopicResponse {
 		CreatedAt:       t.CreatedAt,
 		UpdatedAt:       t.UpdatedAt,
 		Author:          author,
+		QAMode:          t.QAMode,
+		PostCount:       t.PostCount,
+		LastPostAt:      t.LastPostAt,
 	}
 }
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+type TopicModeratorResponse struct {
+	TopicID          uint       `json:"topicId"`
+	AssignedByUserID uint       `json:"assignedByUserId"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	User             UserPublic `json:"user"`
+}
+
+func ToTopicModeratorResponse(m models.TopicModerator) TopicModeratorResponse {
+	return TopicModeratorResponse{
+		TopicID:          m.TopicID,
+		AssignedByUserID: m.AssignedByUserID,
+		CreatedAt:        m.CreatedAt,
+		User:             ToUserPublic(m.User),
+	}
+}
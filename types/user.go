@@ -1,6 +1,10 @@
 package types
 
-import "CVWO-Backend/models"
+import (
+	"time"
+
+	"CVWO-Backend/models"
+)
 
 type UserPublic struct {
 	ID       uint   `json:"id"`
@@ -10,3 +14,21 @@ type UserPublic struct {
 func ToUserPublic(u models.User) UserPublic {
 	return UserPublic{ID: u.ID, Username: u.Username}
 }
+
+type UserProfileResponse struct {
+	ID             uint      `json:"id"`
+	Username       string    `json:"username"`
+	CreatedAt      time.Time `json:"createdAt"`
+	FollowerCount  int64     `json:"followerCount"`
+	FollowingCount int64     `json:"followingCount"`
+	PostCount      int64     `json:"postCount"`
+
+	// Following is only set when the request identifies a viewer.
+	Following *bool `json:"following,omitempty"`
+}
+
+// FollowResponse is one entry in a follower or following list.
+type FollowResponse struct {
+	User       UserPublic `json:"user"`
+	FollowedAt time.Time  `json:"followedAt"`
+}
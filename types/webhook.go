@@ -0,0 +1,68 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"CVWO-Backend/models"
+)
+
+// WebhookResponse carries Secret only when the subscription is created.
+type WebhookResponse struct {
+	ID              uint      `json:"id"`
+	URL             string    `json:"url"`
+	Events          []string  `json:"events"`
+	Description     string    `json:"description"`
+	Active          bool      `json:"active"`
+	Secret          string    `json:"secret,omitempty"`
+	CreatedByUserID uint      `json:"createdByUserId"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+func ToWebhookResponse(s models.WebhookSubscription) WebhookResponse {
+	return WebhookResponse{
+		ID:              s.ID,
+		URL:             s.URL,
+		Events:          s.Events,
+		Description:     s.Description,
+		Active:          s.Active,
+		CreatedByUserID: s.CreatedByUserID,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint            `json:"id"`
+	SubscriptionID uint            `json:"subscriptionId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	RedeliveryOfID *uint           `json:"redeliveryOfId,omitempty"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	ResponseBody   string          `json:"responseBody,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+func ToWebhookDeliveryResponse(d models.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		Event:          d.Event,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		RedeliveryOfID: d.RedeliveryOfID,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		Error:          d.Error,
+		LastAttemptAt:  d.LastAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
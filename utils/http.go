@@ -36,3 +36,28 @@ func ParseUintParam(r *http.Request, key string) (uint, error) {
 	}
 	return uint(u64), nil
 }
+
+func ParseUintQuery(r *http.Request, key string) (uint, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return 0, errors.New("missing query param")
+	}
+	u64, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || u64 == 0 {
+		return 0, errors.New("invalid query param")
+	}
+	return uint(u64), nil
+}
+
+// ParsePagination reads page and pageSize from the query string, falling back
+// to the first page of 20 and capping pageSize at 100.
+func ParsePagination(r *http.Request) (page, pageSize int) {
+	page, pageSize = 1, 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil && v > 0 {
+		pageSize = min(v, 100)
+	}
+	return page, pageSize
+}